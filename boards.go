@@ -0,0 +1,501 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kanban/internal/config"
+	"kanban/internal/db"
+	"kanban/internal/models"
+	"kanban/internal/workspace"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= BOARDS SECTION =========
+
+var boardFlag = flag.String("board", "", "board to open, by title or id (defaults to the last one opened)")
+
+// boardMutatingKeys are the board picker keys that change the boards.
+var boardMutatingKeys = map[string]bool{"a": true, "r": true, "c": true, "d": true}
+
+// defaultColumns are the columns of a new board.
+func defaultColumns() []models.StatusColumn {
+	return []models.StatusColumn{
+		{Name: "To Do", Position: 0, Color: todoColor},
+		{Name: "In Progress", Position: 1, Color: inProgressColor},
+		{Name: "Done", Position: 2, Color: doneColor},
+	}
+}
+
+// boardMemory remembers the board last opened in a storage file, so the
+// next run opens it again. The zero value remembers nothing, as for the
+// memory backend.
+type boardMemory struct {
+	state   *workspace.State
+	storage string
+}
+
+func openBoardMemory(cfg *config.Config) (boardMemory, error) {
+	if cfg.Backend == config.BackendMemory {
+		return boardMemory{}, nil
+	}
+	storage, err := storagePath(cfg)
+	if err != nil {
+		return boardMemory{}, err
+	}
+	state, err := workspace.LoadState(db.DataDir(appName))
+	if err != nil {
+		return boardMemory{}, err
+	}
+	return boardMemory{state: state, storage: storage}, nil
+}
+
+func (b boardMemory) last() int64 {
+	if b.state == nil {
+		return 0
+	}
+	return b.state.LastBoard(b.storage)
+}
+
+func (b boardMemory) remember(id int64) error {
+	if b.state == nil || id == 0 {
+		return nil
+	}
+	return b.state.SetLastBoard(b.storage, id)
+}
+
+// openedBoard returns the board shown when the TUI exited, or 0 if none
+// was.
+func openedBoard(m tea.Model) int64 {
+	switch m := m.(type) {
+	case Model:
+		return m.board.Id
+	case *Model:
+		return m.board.Id
+	}
+	return 0
+}
+
+// findBoard resolves --board, given as a title (in any case) or an id.
+func findBoard(ctx context.Context, store models.BoardStore, name string) (int64, error) {
+	boards, err := store.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, board := range boards {
+		if strings.EqualFold(board.Title, name) {
+			return board.Id, nil
+		}
+	}
+	if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+		for _, board := range boards {
+			if board.Id == id {
+				return id, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("board %q does not exist", name)
+}
+
+type boardAction int
+
+const (
+	boardBrowse boardAction = iota
+	boardCreate
+	boardRename
+	boardDuplicate
+	boardDelete // Waiting for y to confirm
+)
+
+// boardItem shows a board in the picker.
+type boardItem struct {
+	board   models.Board
+	current bool
+}
+
+func (i boardItem) Title() string {
+	if i.current {
+		return i.board.Title + " (open)"
+	}
+	return i.board.Title
+}
+func (i boardItem) FilterValue() string { return i.board.Title }
+func (i boardItem) Description() string {
+	if i.board.Description != "" {
+		return i.board.Description
+	}
+	return "created " + i.board.CreatedAt.Format("2006-01-02")
+}
+
+// columnItem shows a column of the board a task is being sent to.
+type columnItem struct {
+	column models.StatusColumn
+}
+
+func (i columnItem) Title() string       { return i.column.Name }
+func (i columnItem) FilterValue() string { return i.column.Name }
+func (i columnItem) Description() string { return "" }
+
+type boardPane struct {
+	list   list.Model
+	action boardAction
+	input  textinput.Model
+
+	// Set while picking where to send a task: first the board, then one
+	// of its columns
+	send   *models.Task
+	target *models.Board
+}
+
+// openBoards shows the board picker with the open board selected.
+func (m *Model) openBoards() error {
+	m.boards = boardPane{}
+	if err := m.loadBoards(); err != nil {
+		return err
+	}
+	m.mode = Boards
+	return nil
+}
+
+// loadBoards fills the picker, keeping the selection on the open board.
+func (m *Model) loadBoards() error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	boards, err := m.boardRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	items := make([]list.Item, len(boards))
+	selected := 0
+	for i, board := range boards {
+		items[i] = boardItem{board, board.Id == m.board.Id}
+		if board.Id == m.board.Id {
+			selected = i
+		}
+	}
+	lm := list.New(items, createListDelegate(), 0, 0)
+	lm.Title = "Boards"
+	lm = styleListModel(lm)
+	lm.Select(selected)
+
+	m.boards.list = lm
+	m.resizeBoards()
+	return nil
+}
+
+func (m *Model) resizeBoards() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.boards.list.SetSize(m.width-horizontal-2, m.height-18-vertical)
+}
+
+func (m *Model) selectedBoard() (models.Board, bool) {
+	item, ok := m.boards.list.SelectedItem().(boardItem)
+	return item.board, ok
+}
+
+// switchBoard opens the board with the given id and leaves the picker.
+func (m *Model) switchBoard(id int64) error {
+	if err := m.openBoard(id); err != nil {
+		return err
+	}
+	m.mode = Normal
+	return nil
+}
+
+func (m *Model) createBoard(title string) tea.Cmd {
+	board := models.Board{Title: title}
+	boards := m.boardRepo
+	return m.save(func(ctx context.Context) error {
+		return boards.CreateWithColumns(ctx, &board, defaultColumns())
+	}, func(m *Model) tea.Cmd {
+		return m.report(m.switchBoard(board.Id))
+	})
+}
+
+func (m *Model) renameBoard(board models.Board, title string) tea.Cmd {
+	board.Title = title
+	boards := m.boardRepo
+	return m.save(func(ctx context.Context) error {
+		return boards.Update(ctx, &board)
+	}, func(m *Model) tea.Cmd {
+		if board.Id == m.board.Id {
+			m.board.Title = title
+		}
+		return m.report(m.loadBoards())
+	})
+}
+
+func (m *Model) duplicateBoard(source models.Board, title string) tea.Cmd {
+	board := models.Board{Title: title, Description: source.Description}
+	boards := m.boardRepo
+	return m.save(func(ctx context.Context) error {
+		return boards.Duplicate(ctx, source.Id, &board)
+	}, func(m *Model) tea.Cmd {
+		return m.report(m.switchBoard(board.Id))
+	})
+}
+
+// deleteBoard deletes a board with its columns and tasks. Deleting the
+// open board opens another one. The undo history is dropped, as it may
+// hold operations on the board's tasks and columns, or on tasks sent from
+// it.
+func (m *Model) deleteBoard(board models.Board) tea.Cmd {
+	boards := m.boardRepo
+	return m.save(func(ctx context.Context) error {
+		return boards.Delete(ctx, board.Id)
+	}, func(m *Model) tea.Cmd {
+		m.undoStack = undoStack{}
+		return m.report(m.showDeletedBoard(board))
+	})
+}
+
+// showDeletedBoard opens another board in place of board if it was open,
+// and refreshes the picker.
+func (m *Model) showDeletedBoard(board models.Board) error {
+	if board.Id == m.board.Id {
+		// As when the board is deleted elsewhere, which also covers the
+		// other boards having gone meanwhile
+		if err := m.loadBoard(); err != nil {
+			return err
+		}
+		m.tagFilter.tag = ""
+		if err := m.openBoard(m.board.Id); err != nil {
+			return err
+		}
+	}
+	return m.loadBoards()
+}
+
+// openSend shows the board picker for sending task to another board.
+func (m *Model) openSend(task models.Task) error {
+	if err := m.openBoards(); err != nil {
+		return err
+	}
+	m.boards.send = &task
+	m.boards.list.Title = fmt.Sprintf("Send %q to board", task.Title())
+	for i, item := range m.boards.list.Items() {
+		if !item.(boardItem).current {
+			// Sending is mostly to another board
+			m.boards.list.Select(i)
+			break
+		}
+	}
+	return nil
+}
+
+// pickSendBoard lists the columns of board for the task being sent.
+func (m *Model) pickSendBoard(id int64) error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	board, err := m.boardRepo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	items := make([]list.Item, len(board.Columns))
+	for i, column := range board.Columns {
+		items[i] = columnItem{column}
+	}
+	m.boards.target = board
+	m.boards.list.ResetFilter()
+	m.boards.list.SetItems(items)
+	m.boards.list.Select(0)
+	m.boards.list.Title = fmt.Sprintf("Send %q to column on %s", m.boards.send.Title(), board.Title)
+	return nil
+}
+
+// sendTask moves task to the top of the column with the given id, which is
+// usually on another board. Moves within the open board are plain moves.
+func (m *Model) sendTask(task models.Task, board models.Board, column models.StatusColumn) tea.Cmd {
+	if target := m.columnIndex(column.Id); target >= 0 {
+		return m.moveTask(task, target, 0)
+	}
+
+	index := task.Position
+	var removed tea.Cmd
+	if i, j, ok := m.findTask(task.Id); ok {
+		index = j
+		removed = m.columnCmd(i, removeItem(&m.columns[i], j))
+	}
+
+	tasks := m.taskRepo
+	return tea.Batch(removed, m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		id := m.storedId(task.Id)
+		if id == 0 {
+			return nil, nil
+		}
+		original := task
+		original.Id = id
+		original.Position = index
+
+		run := func(ctx context.Context) error { return tasks.MoveToBoard(ctx, id, column.Id, 0) }
+		finish := func(m *Model, err error) tea.Cmd {
+			if err != nil {
+				return m.putBack(original, index)
+			}
+			m.setStoredVersion(id, m.storedVersion(id, task.Version)+1)
+			m.undoStack.record(sendOp(original, column.Id))
+			return m.notify(severityInfo, "Sent %q to %s › %s", task.Title(), board.Title, column.Name)
+		}
+		return run, finish
+	}))
+}
+
+func handleSend(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if m.boards.target == nil {
+			m.mode = Normal
+			return m, nil
+		}
+		return m, m.report(m.openSend(*m.boards.send))
+	case "enter":
+		if m.boards.target == nil {
+			if board, ok := m.selectedBoard(); ok {
+				return m, m.report(m.pickSendBoard(board.Id))
+			}
+			return m, nil
+		}
+		item, ok := m.boards.list.SelectedItem().(columnItem)
+		if !ok {
+			return m, nil
+		}
+		m.mode = Normal
+		return m, m.sendTask(*m.boards.send, *m.boards.target, item.column)
+	}
+	var cmd tea.Cmd
+	m.boards.list, cmd = m.boards.list.Update(msg)
+	return m, cmd
+}
+
+// prompt shows the board input for action, holding value.
+func (p *boardPane) prompt(action boardAction, label, value string) tea.Cmd {
+	input := textinput.New()
+	input.Prompt = label
+	input.CharLimit = 100
+	input.Width = 40
+	input.SetValue(value)
+	p.action = action
+	p.input = input
+	return p.input.Focus()
+}
+
+func handleBoards(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, m.quit()
+	}
+	switch m.boards.action {
+	case boardCreate, boardRename, boardDuplicate:
+		return handleBoardInput(msg, m)
+	case boardDelete:
+		m.boards.action = boardBrowse
+		if board, ok := m.selectedBoard(); ok && msg.String() == "y" {
+			return m, m.deleteBoard(board)
+		}
+		return m, nil
+	}
+	if m.boards.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.boards.list, cmd = m.boards.list.Update(msg)
+		return m, cmd
+	}
+	if m.boards.send != nil {
+		return handleSend(msg, m)
+	}
+
+	key := msg.String()
+	if m.readonly && boardMutatingKeys[key] {
+		return m, nil
+	}
+	if !m.idle() && (key == "enter" || boardMutatingKeys[key]) {
+		return m, m.report(errStillSaving)
+	}
+	board, selected := m.selectedBoard()
+	switch key {
+	case "esc", "q", "b":
+		m.mode = Normal
+	case "enter":
+		if selected {
+			return m, m.report(m.switchBoard(board.Id))
+		}
+	case "a":
+		return m, m.boards.prompt(boardCreate, "New board: ", "")
+	case "r":
+		if selected {
+			return m, m.boards.prompt(boardRename, "Title: ", board.Title)
+		}
+	case "c":
+		if selected {
+			return m, m.boards.prompt(boardDuplicate, "Copy as: ", "Copy of "+board.Title)
+		}
+	case "d":
+		if len(m.boards.list.Items()) == 1 {
+			return m, m.notify(severityWarning, "The last board cannot be deleted")
+		}
+		if selected {
+			m.boards.action = boardDelete
+		}
+	default:
+		var cmd tea.Cmd
+		m.boards.list, cmd = m.boards.list.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func handleBoardInput(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.boards.action = boardBrowse
+		return m, nil
+	case "enter":
+		title := strings.TrimSpace(m.boards.input.Value())
+		if title == "" {
+			return m, m.notify(severityWarning, "A board needs a title")
+		}
+		action := m.boards.action
+		m.boards.action = boardBrowse
+		board, _ := m.selectedBoard()
+		switch action {
+		case boardCreate:
+			return m, m.createBoard(title)
+		case boardRename:
+			return m, m.renameBoard(board, title)
+		case boardDuplicate:
+			return m, m.duplicateBoard(board, title)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.boards.input, cmd = m.boards.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) boardsView() string {
+	help := "\nEnter to open, a to add, r to rename, c to duplicate, d to delete, / to filter, esc to go back\n"
+	if m.readonly {
+		help = "\nEnter to open, / to filter, esc to go back\n"
+	}
+	if m.boards.send != nil {
+		help = "\nEnter to pick, / to filter, esc to go back\n"
+	}
+	switch m.boards.action {
+	case boardCreate, boardRename, boardDuplicate:
+		help = "\n" + m.boards.input.View() + "  Enter to save, Esc to cancel\n"
+	case boardDelete:
+		board, _ := m.selectedBoard()
+		help = fmt.Sprintf("\nDelete %q with all of its columns and tasks? y to confirm, any other key to cancel\n", board.Title)
+	}
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.boards.list.View()) + help
+}
+
+// ========= END BOARDS SECTION =========
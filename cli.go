@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"kanban/internal/config"
+	"kanban/internal/db"
+	"kanban/internal/lock"
+	"kanban/internal/models"
+	"kanban/internal/workspace"
+)
+
+// ========= COMMANDS SECTION =========
+
+// runCommand dispatches `kanban <command> [args]` invocations that do not
+// start the TUI.
+func runCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "doctor":
+		database, l, err := openSQLite(cfg, "doctor", true)
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+		defer database.Close()
+		return runDoctor(ctx, database, args[1:], os.Stdin, os.Stdout)
+	case "backup":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s backup <file>", appName)
+		}
+		// Online backups are safe while another instance writes
+		database, _, err := openSQLite(cfg, "backup", false)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+		if err := database.Backup(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Backed up %s to %s\n", database.Path(), args[1])
+		return nil
+	case "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s restore <file>", appName)
+		}
+		if *readonlyFlag {
+			return fmt.Errorf("restore cannot be used with --readonly")
+		}
+		database, l, err := openSQLite(cfg, "restore", true)
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+		defer database.Close()
+		return runRestore(ctx, database, args[1], os.Stdin, os.Stdout)
+	case "workspaces":
+		return runWorkspaces(ctx, cfg, args[1:], os.Stdin, os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// openSQLite opens the selected storage for a command that only works
+// against the SQLite backend, taking the storage lock if exclusive is set.
+func openSQLite(cfg *config.Config, command string, exclusive bool) (*db.TaskDB, *lock.Lock, error) {
+	if cfg.Backend != config.BackendSQLite {
+		return nil, nil, fmt.Errorf("%s requires the %s backend", command, config.BackendSQLite)
+	}
+	_, database, l, err := openStorage(cfg, exclusive)
+	return database, l, err
+}
+
+func runWorkspaces(ctx context.Context, cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	if cfg.Backend == config.BackendMemory {
+		return fmt.Errorf("the %s backend has no workspaces", config.BackendMemory)
+	}
+	ws := workspaces(cfg)
+
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "list":
+		names, err := ws.List()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			path, _ := ws.Path(name)
+			fmt.Fprintf(out, "%-20s %s\n", name, path)
+		}
+		return nil
+	case "create":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s workspaces create <name>", appName)
+		}
+		exists, err := ws.Exists(args[1])
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%q: %w", args[1], workspace.ErrExists)
+		}
+		path, _ := ws.Path(args[1])
+		_, database, err := openBackend(cfg, path)
+		if err != nil {
+			return err
+		}
+		if database != nil {
+			database.Close()
+		}
+		fmt.Fprintf(out, "Created workspace %q at %s\n", args[1], path)
+		return nil
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s workspaces remove <name>", appName)
+		}
+		exists, err := ws.Exists(args[1])
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%q: %w", args[1], workspace.ErrNotFound)
+		}
+		fmt.Fprintf(out, "Remove workspace %q and all of its boards? [y/N] ", args[1])
+		answer, err := readLine(ctx, in)
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			fmt.Fprintln(out, "Left unchanged.")
+			return nil
+		}
+		// Refuse to pull the file out from under a running instance, and
+		// hold the lock until the files are gone so none can open it
+		// meanwhile. The lock file itself goes once released, as Windows
+		// cannot delete it while held.
+		path, _ := ws.Path(args[1])
+		l, err := lockStorage(path)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(path + ".lock")
+		defer l.Release()
+		if err := ws.Remove(args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed workspace %q\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown workspaces command %q (expected list, create or remove)", sub)
+	}
+}
+
+func runDoctor(ctx context.Context, database *db.TaskDB, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := database.FindOrphans(ctx)
+	if err != nil {
+		return err
+	}
+	if report.Empty() {
+		fmt.Fprintln(out, "No problems found.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Found %d orphaned column(s) and %d orphaned task(s):\n", len(report.Columns), len(report.Tasks))
+	for _, c := range report.Columns {
+		fmt.Fprintf(out, "  column %d %q (missing board %d)\n", c.Id, c.Name, c.BoardId)
+	}
+	for _, t := range report.Tasks {
+		fmt.Fprintf(out, "  task %d %q (board %d, column %d)\n", t.Id, t.Title, t.BoardId, t.StatusColumnId)
+	}
+
+	boards, err := models.NewBoardRepository(database).GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	prompt := "[d]elete them or leave them [a]lone? "
+	if len(boards) > 0 {
+		prompt = fmt.Sprintf("[r]eassign them to board %q, [d]elete them or leave them [a]lone? ", boards[0].Title)
+	}
+	fmt.Fprint(out, prompt)
+
+	answer, err := readLine(ctx, in)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r":
+		if len(boards) == 0 {
+			return fmt.Errorf("no board to reassign to")
+		}
+		if err := database.ReassignOrphans(ctx, report, boards[0].Id); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Reassigned.")
+	case "d":
+		if err := database.DeleteOrphans(ctx, report); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Deleted.")
+	default:
+		fmt.Fprintln(out, "Left unchanged.")
+	}
+	return nil
+}
+
+func runRestore(ctx context.Context, database *db.TaskDB, src string, in io.Reader, out io.Writer) error {
+	version, err := db.ValidateBackup(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Replace %s with %s (schema version %d)? [y/N] ", database.Path(), src, version)
+	answer, err := readLine(ctx, in)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		fmt.Fprintln(out, "Left unchanged.")
+		return nil
+	}
+
+	saved, err := database.Restore(ctx, src)
+	if saved != "" {
+		fmt.Fprintf(out, "Previous contents saved to %s\n", saved)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Restored.")
+	return nil
+}
+
+// readLine reads a single line of user input, giving up when ctx is
+// cancelled so Ctrl+C at a prompt exits cleanly.
+func readLine(ctx context.Context, in io.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err == io.EOF {
+			err = nil
+		}
+		ch <- result{line, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-ch:
+		return r.line, r.err
+	}
+}
+
+// ========= END COMMANDS SECTION =========
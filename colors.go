@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ========= COLORS SECTION =========
+
+// columnPalette are the colors offered by the column color picker, the
+// first being no color. Any other #rrggbb color can still be typed in.
+var columnPalette = []string{
+	"",
+	todoColor,
+	"#ff9f43",
+	"#f4d35e",
+	"#6ab04c",
+	inProgressColor,
+	doneColor,
+	"#6c5ce7",
+	"#e056fd",
+	"#95a5a6",
+}
+
+// darkBackground is whether the terminal background is dark, detected once
+// at startup. Column colors are adjusted to stay readable against it.
+var darkBackground = true
+
+// minContrast is the contrast ratio a column color is brought up to
+// against the terminal background, the WCAG minimum for large text and
+// graphics.
+const minContrast = 3
+
+type rgb struct {
+	r, g, b float64 // 0 to 1
+}
+
+// parseColor reads a #rgb or #rrggbb color.
+func parseColor(hex string) (rgb, bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255}, true
+}
+
+func (c rgb) hex() string {
+	channel := func(v float64) int { return int(math.Round(v * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", channel(c.r), channel(c.g), channel(c.b))
+}
+
+// mix moves c towards to by amount, from 0 (c) to 1 (to).
+func (c rgb) mix(to rgb, amount float64) rgb {
+	return rgb{
+		c.r + (to.r-c.r)*amount,
+		c.g + (to.g-c.g)*amount,
+		c.b + (to.b-c.b)*amount,
+	}
+}
+
+// luminance is the relative luminance of c, as WCAG defines it.
+func (c rgb) luminance() float64 {
+	linear := func(v float64) float64 {
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.r) + 0.7152*linear(c.g) + 0.0722*linear(c.b)
+}
+
+func contrast(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	return (max(la, lb) + 0.05) / (min(la, lb) + 0.05)
+}
+
+var (
+	black = rgb{0, 0, 0}
+	white = rgb{1, 1, 1}
+)
+
+// background approximates the terminal background.
+func background() rgb {
+	if darkBackground {
+		return black
+	}
+	return white
+}
+
+// readable returns c, lightened on dark terminals or darkened on light
+// ones until it stands out from the background.
+func readable(c rgb) rgb {
+	bg, fg := background(), white
+	if !darkBackground {
+		fg = black
+	}
+	for amount := 0.0; amount < 1; amount += 0.1 {
+		adjusted := c.mix(fg, amount)
+		if contrast(adjusted, bg) >= minContrast {
+			return adjusted
+		}
+	}
+	return fg
+}
+
+// columnColors are the colors a column is drawn with.
+type columnColors struct {
+	border    lipgloss.Color // Focused border and card accent
+	dimBorder lipgloss.Color // Unfocused border
+	title     lipgloss.Color // Title background
+	titleText lipgloss.Color // Title text, black or white, whichever reads better
+}
+
+// colorsOf returns the colors of column, or false if it has none, in which
+// case the default styles apply.
+func colorsOf(column models.StatusColumn) (columnColors, bool) {
+	c, ok := parseColor(column.Color)
+	if !ok {
+		return columnColors{}, false
+	}
+	border := readable(c)
+	text := black
+	if contrast(white, c) > contrast(black, c) {
+		text = white
+	}
+	return columnColors{
+		border:    lipgloss.Color(border.hex()),
+		dimBorder: lipgloss.Color(border.mix(background(), 0.5).hex()),
+		title:     lipgloss.Color(c.hex()),
+		titleText: lipgloss.Color(text.hex()),
+	}, true
+}
+
+// styleColumnList colors a column's title and its selected card, and
+// draws its cards with the board's tags.
+func styleColumnList(lm list.Model, column models.StatusColumn, tags boardTags) list.Model {
+	delegate := cardDelegate{createListDelegate(), tags}
+	if colors, ok := colorsOf(column); ok {
+		lm.Styles.Title = lm.Styles.Title.Background(colors.title).Foreground(colors.titleText)
+		delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(colors.border).BorderLeftForeground(colors.border)
+		delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.BorderLeftForeground(colors.border)
+	}
+	lm.SetDelegate(delegate)
+	return lm
+}
+
+// columnStyleOf returns the border style of a column.
+func columnStyleOf(column models.StatusColumn, focused bool) lipgloss.Style {
+	style := unfocusedColumnStyle
+	if focused {
+		style = focusedColumnStyle
+	}
+	if colors, ok := colorsOf(column); ok {
+		if focused {
+			return style.BorderForeground(colors.border)
+		}
+		return style.BorderForeground(colors.dimBorder)
+	}
+	return style
+}
+
+// swatch renders a sample of color, or of the default colors for "".
+func swatch(color string) string {
+	if colors, ok := colorsOf(models.StatusColumn{Color: color}); ok {
+		return lipgloss.NewStyle().Foreground(colors.border).Render("██")
+	}
+	return lipgloss.NewStyle().Foreground(coralRed).Render("░░")
+}
+
+// ========= END COLORS SECTION =========
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= COLUMNS SECTION =========
+
+type columnAction int
+
+const (
+	columnBrowse columnAction = iota
+	columnAdd
+	columnRename
+	columnPick    // Picking a color from columnPalette
+	columnRecolor // Typing a color
+	columnDelete  // Picking the column that receives the tasks
+)
+
+type columnPane struct {
+	action columnAction
+	input  textinput.Model
+	target int // Index of the column receiving a deleted column's tasks
+	pick   int // Index of the picked color in columnPalette
+}
+
+// colorPattern matches the colors a column accepts: empty for the default,
+// or #rgb and #rrggbb.
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6})?$`)
+
+// columnMutatingKeys are the column mode keys that change the columns.
+var columnMutatingKeys = map[string]bool{"a": true, "r": true, "c": true, "p": true, "<": true, ">": true, "d": true}
+
+// addColumnOp undoes adding a column by deleting it again. The redo
+// recreates it under the same id, so later operations still find it.
+func addColumnOp(column models.StatusColumn) operation {
+	return operation{
+		boardId:  column.BoardId,
+		columnId: column.Id,
+		undo:     func(ctx context.Context, s models.Stores) error { return deleteEmptyColumn(ctx, s, column) },
+		redo: func(ctx context.Context, s models.Stores) error {
+			c := column
+			return s.Columns.Create(ctx, &c)
+		},
+	}
+}
+
+// deleteEmptyColumnOp is the inverse of addColumnOp, for deleting a column
+// that holds no tasks.
+func deleteEmptyColumnOp(column models.StatusColumn) operation {
+	op := addColumnOp(column)
+	op.undo, op.redo = op.redo, op.undo
+	return op
+}
+
+// deleteEmptyColumn deletes column unless tasks, trashed ones included,
+// were put in it since, as deleting it would delete them too.
+func deleteEmptyColumn(ctx context.Context, s models.Stores, column models.StatusColumn) error {
+	tasks, err := columnTasks(ctx, s.Tasks, column)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		return fmt.Errorf("column %q is no longer empty", column.Name)
+	}
+	return s.Columns.Delete(ctx, column.Id)
+}
+
+// updateColumnOp swaps the name, color and sort of a column between
+// before and after.
+func updateColumnOp(before, after models.StatusColumn) operation {
+	return operation{
+		boardId:  after.BoardId,
+		columnId: after.Id,
+		undo:     func(ctx context.Context, s models.Stores) error { return s.Columns.Update(ctx, &before) },
+		redo:     func(ctx context.Context, s models.Stores) error { return s.Columns.Update(ctx, &after) },
+	}
+}
+
+func moveColumnOp(column models.StatusColumn, position int) operation {
+	return operation{
+		boardId:  column.BoardId,
+		columnId: column.Id,
+		undo: func(ctx context.Context, s models.Stores) error {
+			return s.Columns.Move(ctx, column.Id, column.Position)
+		},
+		redo: func(ctx context.Context, s models.Stores) error { return s.Columns.Move(ctx, column.Id, position) },
+	}
+}
+
+// deleteColumnOp undoes deleting a column by recreating it and moving its
+// tasks, in their old order, back out of targetId.
+func deleteColumnOp(column models.StatusColumn, targetId int64) operation {
+	var tasks []models.Task // As they were when the column was deleted
+	return operation{
+		boardId:  column.BoardId,
+		columnId: column.Id,
+		undo: func(ctx context.Context, s models.Stores) error {
+			c := column
+			if err := s.Columns.Create(ctx, &c); err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				// Past the end, so each lands after the one before
+				if err := s.Tasks.MoveToColumn(ctx, task.Id, column.Id, len(tasks)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		redo: func(ctx context.Context, s models.Stores) error {
+			var err error
+			if tasks, err = columnTasks(ctx, s.Tasks, column); err != nil {
+				return err
+			}
+			return s.Columns.DeleteMovingTasks(ctx, column.Id, targetId)
+		},
+	}
+}
+
+// openColumns switches to column mode, where the focused column can be
+// added to, renamed, recolored, moved and deleted.
+func (m *Model) openColumns() {
+	m.columnPane = columnPane{}
+	m.mode = Columns
+}
+
+// focusedColumn returns the column in focus.
+func (m *Model) focusedColumn() models.StatusColumn {
+	return m.board.Columns[m.focused]
+}
+
+// prompt shows the column input for action, holding value.
+func (m *Model) prompt(action columnAction, label, value string) tea.Cmd {
+	input := textinput.New()
+	input.Prompt = label
+	input.CharLimit = 50
+	input.Width = 30
+	input.SetValue(value)
+	m.columnPane.action = action
+	m.columnPane.input = input
+	return m.columnPane.input.Focus()
+}
+
+func (m *Model) addColumn(name string) tea.Cmd {
+	column := models.StatusColumn{BoardId: m.board.Id, Name: name, Position: m.focused + 1}
+	columns := m.columnRepo
+	return m.save(func(ctx context.Context) error {
+		return columns.Create(ctx, &column)
+	}, func(m *Model) tea.Cmd {
+		m.undoStack.record(addColumnOp(column))
+		if err := m.openBoard(column.BoardId); err != nil {
+			return m.report(err)
+		}
+		m.focused = max(m.columnIndex(column.Id), 0)
+		return nil
+	})
+}
+
+func (m *Model) updateColumn(update func(column *models.StatusColumn)) tea.Cmd {
+	before := m.focusedColumn()
+	after := before
+	update(&after)
+	return m.perform(updateColumnOp(before, after))
+}
+
+func (m *Model) moveColumn(delta int) tea.Cmd {
+	column := m.focusedColumn()
+	position := column.Position + delta
+	if position < 0 || position >= len(m.board.Columns) {
+		return nil
+	}
+	return m.perform(moveColumnOp(column, position))
+}
+
+// columnTasks returns every task of a column, trashed ones included, in
+// their order.
+func columnTasks(ctx context.Context, store models.TaskStore, column models.StatusColumn) ([]models.Task, error) {
+	tasks, err := store.GetByColumnId(ctx, column.Id)
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := store.GetDeletedByBoardId(ctx, column.BoardId)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range trashed {
+		if task.StatusColumnId == column.Id {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Rank != tasks[j].Rank {
+			return tasks[i].Rank < tasks[j].Rank
+		}
+		return tasks[i].Id < tasks[j].Id
+	})
+	return tasks, nil
+}
+
+// startDeleteColumn deletes the focused column right away when it holds no
+// tasks, and otherwise asks where its tasks should go. Trashed tasks count,
+// as deleting the column would delete them for good.
+func (m *Model) startDeleteColumn() tea.Cmd {
+	if len(m.board.Columns) == 1 {
+		return m.notify(severityWarning, "A board needs at least one column")
+	}
+	column := m.focusedColumn()
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	tasks, err := columnTasks(ctx, m.taskRepo, column)
+	if err != nil {
+		return m.report(err)
+	}
+	if len(tasks) == 0 {
+		return m.perform(deleteEmptyColumnOp(column))
+	}
+
+	m.columnPane.action = columnDelete
+	m.columnPane.target = m.focused - 1
+	if m.columnPane.target < 0 {
+		m.columnPane.target = m.focused + 1
+	}
+	return nil
+}
+
+func (m *Model) deleteColumn() tea.Cmd {
+	column := m.focusedColumn()
+	target := m.board.Columns[m.columnPane.target]
+	return m.perform(deleteColumnOp(column, target.Id))
+}
+
+func handleColumns(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, m.quit()
+	}
+	switch m.columnPane.action {
+	case columnAdd, columnRename, columnRecolor:
+		return handleColumnInput(msg, m)
+	case columnPick:
+		return handleColumnPick(msg, m)
+	case columnDelete:
+		return handleColumnDelete(msg, m)
+	}
+
+	key := msg.String()
+	if !m.idle() && columnMutatingKeys[key] {
+		return m, m.report(errStillSaving)
+	}
+	switch key {
+	case "esc", "q":
+		m.mode = Normal
+	case "left", "h":
+		if m.focused > 0 {
+			m.focused--
+		}
+	case "right", "l":
+		if m.focused < len(m.columns)-1 {
+			m.focused++
+		}
+	case "a":
+		return m, m.prompt(columnAdd, "New column: ", "")
+	case "r":
+		return m, m.prompt(columnRename, "Name: ", m.focusedColumn().Name)
+	case "c":
+		m.openPicker()
+	case "p":
+		return m, m.updateColumn(func(c *models.StatusColumn) {
+			c.Sort = models.SortPriority
+			if m.focusedColumn().Sort == models.SortPriority {
+				c.Sort = models.SortManual
+			}
+		})
+	case "<":
+		return m, m.moveColumn(-1)
+	case ">":
+		return m, m.moveColumn(1)
+	case "u":
+		return m, m.undo()
+	case "ctrl+r":
+		return m, m.redo()
+	case "d":
+		return m, m.startDeleteColumn()
+	}
+	return m, nil
+}
+
+func handleColumnInput(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.columnPane.action = columnBrowse
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.columnPane.input.Value())
+		var cmd tea.Cmd
+		switch m.columnPane.action {
+		case columnAdd, columnRename:
+			if value == "" {
+				return m, m.notify(severityWarning, "A column needs a name")
+			}
+			if m.columnPane.action == columnAdd {
+				cmd = m.addColumn(value)
+			} else {
+				cmd = m.updateColumn(func(c *models.StatusColumn) { c.Name = value })
+			}
+		case columnRecolor:
+			if !colorPattern.MatchString(value) {
+				return m, m.notify(severityWarning, "Colors look like #4ecdc4")
+			}
+			cmd = m.updateColumn(func(c *models.StatusColumn) { c.Color = value })
+		}
+		m.columnPane.action = columnBrowse
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	m.columnPane.input, cmd = m.columnPane.input.Update(msg)
+	return m, cmd
+}
+
+// openPicker shows the color picker on the focused column's color, or on
+// no color if it is not in the palette.
+func (m *Model) openPicker() {
+	m.columnPane.action = columnPick
+	m.columnPane.pick = 0
+	for i, color := range columnPalette {
+		if strings.EqualFold(color, m.focusedColumn().Color) {
+			m.columnPane.pick = i
+		}
+	}
+}
+
+func handleColumnPick(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.columnPane.action = columnBrowse
+	case "left", "h":
+		m.columnPane.pick = max(m.columnPane.pick-1, 0)
+	case "right", "l":
+		m.columnPane.pick = min(m.columnPane.pick+1, len(columnPalette)-1)
+	case "#":
+		return m, m.prompt(columnRecolor, "Color (#rrggbb): ", m.focusedColumn().Color)
+	case "enter":
+		m.columnPane.action = columnBrowse
+		color := columnPalette[m.columnPane.pick]
+		return m, m.updateColumn(func(c *models.StatusColumn) { c.Color = color })
+	}
+	return m, nil
+}
+
+func handleColumnDelete(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	// step moves the target by delta, skipping the column being deleted
+	step := func(delta int) {
+		target := m.columnPane.target + delta
+		if target == m.focused {
+			target += delta
+		}
+		if target >= 0 && target < len(m.board.Columns) {
+			m.columnPane.target = target
+		}
+	}
+	switch msg.String() {
+	case "esc":
+		m.columnPane.action = columnBrowse
+	case "left", "h":
+		step(-1)
+	case "right", "l":
+		step(1)
+	case "enter":
+		m.columnPane.action = columnBrowse
+		return m, m.deleteColumn()
+	}
+	return m, nil
+}
+
+func (m Model) columnsHelp() string {
+	switch m.columnPane.action {
+	case columnAdd, columnRename, columnRecolor:
+		return "\n" + m.columnPane.input.View() + "  Enter to save, Esc to cancel\n"
+	case columnPick:
+		swatches := make([]string, len(columnPalette))
+		for i, color := range columnPalette {
+			swatches[i] = " " + swatch(color) + " "
+			if i == m.columnPane.pick {
+				swatches[i] = "[" + swatch(color) + "]"
+			}
+		}
+		return "\n" + strings.Join(swatches, "") + "  ← → to pick, Enter to save, # to type a color, Esc to cancel\n"
+	case columnDelete:
+		column := m.board.Columns[m.focused]
+		target := m.board.Columns[m.columnPane.target]
+		return fmt.Sprintf("\nDelete %q and move its tasks, trashed ones included, to %q? ← → to pick another column, Enter to confirm, Esc to cancel\n", column.Name, target.Name)
+	}
+	return "\nColumns: ← → to pick, a to add after, r to rename, c to recolor, p to sort by priority or by hand, < > to move, d to delete, u/ctrl+r to undo/redo, Esc to go back\n"
+}
+
+// ========= END COLUMNS SECTION =========
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"kanban/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= CONFLICT SECTION =========
+
+// conflictPane holds an edit that was rejected because the task changed in
+// storage after it was read.
+type conflictPane struct {
+	base   models.Task // as read when editing started
+	mine   models.Task // with the user's edits applied
+	theirs models.Task // as stored now
+}
+
+// columnName resolves a column on the current board for display.
+func (m *Model) columnName(id int64) string {
+	if column := m.board.GetColumnById(id); column != nil {
+		return column.Name
+	}
+	return "another column"
+}
+
+// showConflict opens the conflict dialog for an edit that storage
+// rejected, showing the stored task on the board meanwhile. If the user has
+// moved on to another mode the edit is reported as not saved instead.
+func (m *Model) showConflict(base, mine, theirs models.Task) tea.Cmd {
+	m.setStoredVersion(theirs.Id, theirs.Version)
+	var shown tea.Cmd
+	if i, j, ok := m.findTask(theirs.Id); ok {
+		shown = m.columnCmd(i, m.columns[i].SetItem(j, theirs))
+	}
+	if m.mode != Normal {
+		return tea.Batch(shown, m.notify(severityWarning, "%q was changed elsewhere; your edit was not saved", mine.Title()))
+	}
+
+	m.conflict = conflictPane{base: base, mine: mine, theirs: theirs}
+	m.inputPane.load(mine)
+	m.inputPane.taskId = theirs.Id
+	m.mode = Conflict
+	return shown
+}
+
+// resolveConflict queues writing task over the stored version. Another
+// conflict keeps the dialog open with the newer stored task.
+func (m *Model) resolveConflict(task models.Task) tea.Cmd {
+	tasks := m.taskRepo
+	return m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		theirs := m.conflict.theirs
+		task.Version = m.storedVersion(task.Id, task.Version)
+
+		run := func(ctx context.Context) error { return tasks.Update(ctx, &task) }
+		finish := func(m *Model, err error) tea.Cmd {
+			var conflict *models.ConflictError
+			if errors.As(err, &conflict) {
+				m.setStoredVersion(task.Id, conflict.Current.Version)
+				m.conflict.theirs = conflict.Current
+				return nil
+			}
+			if err != nil {
+				return nil
+			}
+			m.undoStack.record(editOp(theirs, task))
+			return m.closeConflict(task)
+		}
+		return run, finish
+	})
+}
+
+// closeConflict leaves the dialog and shows the task as now stored.
+func (m *Model) closeConflict(task models.Task) tea.Cmd {
+	m.inputPane.clear()
+	m.inputPane.focused = 0
+	m.inputPane.listIndex = -1
+	m.mode = Normal
+
+	m.setStoredVersion(task.Id, task.Version)
+	i, j, ok := m.findTask(task.Id)
+	if !ok || task.DeletedAt != nil || m.board.Columns[i].Id != task.StatusColumnId {
+		// Moved or trashed elsewhere; the next reload puts it in place
+		m.stale = true
+		return nil
+	}
+	cmd := m.columnCmd(i, m.columns[i].SetItem(j, task))
+	m.selectTask(task.Id)
+	return cmd
+}
+
+func handleConflict(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	c := m.conflict
+	key := msg.String()
+	if key != "ctrl+c" && !m.idle() {
+		// The dialog may be about to show a newer stored task
+		return m, m.report(errStillSaving)
+	}
+	switch key {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc":
+		// Back to the edit, which still holds the user's text
+		if !m.selectTask(c.theirs.Id) {
+			return m, m.closeConflict(c.theirs)
+		}
+		m.inputPane.listIndex = m.columns[m.focused].GlobalIndex()
+		m.mode = Insert
+		m.inputPane.focused = 0
+		return m, m.inputPane.titleInput.Focus()
+	case "r":
+		return m, m.closeConflict(c.theirs)
+	case "o":
+		task := c.mine
+		task.StatusColumnId = c.theirs.StatusColumnId
+		task.Position = c.theirs.Position
+		task.Version = c.theirs.Version
+		return m, m.resolveConflict(task)
+	case "m":
+		return m, m.resolveConflict(models.MergeTask(c.base, c.mine, c.theirs))
+	}
+	return m, nil
+}
+
+// describeChanges lists the changes from before to after, one per line.
+func (m *Model) describeChanges(before, after models.Task) string {
+	var lines []string
+	for _, event := range models.TaskChanges(before, after, m.columnName) {
+		lines = append(lines, "  "+historyItem{event}.Title())
+	}
+	if after.DeletedAt != nil && before.DeletedAt == nil {
+		lines = append(lines, "  Moved to trash")
+	}
+	if len(lines) == 0 {
+		return "  (no visible changes)"
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) conflictView() string {
+	c := m.conflict
+	body := "This task was changed elsewhere while you were editing it.\n\n" +
+		"Changed elsewhere:\n" + m.describeChanges(c.base, c.theirs) + "\n\n" +
+		"Your changes:\n" + m.describeChanges(c.base, c.mine)
+	help := "\nr to reload theirs and drop yours, o to overwrite with yours, m to merge (yours win where both changed), esc to keep editing\n"
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(body) + help
+}
+
+// ========= END CONFLICT SECTION =========
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// ========= DUE DATES SECTION =========
+
+// dueLabel describes when a task is due relative to now, as cards and My
+// Tasks show it.
+func dueLabel(due, now time.Time) string {
+	days := models.DaysUntil(due, now)
+	due = due.UTC()
+	switch {
+	case days < -1:
+		return fmt.Sprintf("overdue by %d days", -days)
+	case days == -1:
+		return "due yesterday"
+	case days == 0:
+		return "due today"
+	case days == 1:
+		return "due tomorrow"
+	case days < 7:
+		return "due " + due.Format("Mon")
+	case due.Year() == now.Year():
+		return "due " + due.Format("Jan 2")
+	}
+	return "due " + due.Format("Jan 2 2006")
+}
+
+// formatDueDate shows a due date in the task form, where it can be typed
+// back as is.
+func formatDueDate(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.UTC().Format("2006-01-02")
+}
+
+// highlightDue colors the title of a card due today or overdue.
+func highlightDue(d list.DefaultDelegate, due *time.Time, now time.Time) list.DefaultDelegate {
+	if due == nil {
+		return d
+	}
+	days := models.DaysUntil(*due, now)
+	switch {
+	case days < 0:
+		d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(coralRed).Bold(true)
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(coralRed).Bold(true)
+	case days == 0:
+		d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(sandYellow)
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(sandYellow)
+	}
+	return d
+}
+
+// dueView shows the due date field with the day it reads as, or why it
+// does not read as one.
+func (ip inputPane) dueView() string {
+	view := ip.dueInput.View()
+	if ip.focused != 4 || ip.dueInput.Value() == "" {
+		return view
+	}
+	due, err := models.ParseDue(ip.dueInput.Value(), time.Now())
+	if err != nil {
+		return view + " (?)"
+	}
+	return view + " (" + due.Format("Mon Jan 2 2006") + ")"
+}
+
+// ========= END DUE DATES SECTION =========
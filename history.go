@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= HISTORY SECTION =========
+
+// historyItem shows one change to a task and when it happened.
+type historyItem struct {
+	event models.TaskEvent
+}
+
+func (i historyItem) FilterValue() string { return i.Title() }
+func (i historyItem) Description() string {
+	return i.event.CreatedAt.Format("2006-01-02 15:04") + " · " + formatAge(time.Since(i.event.CreatedAt))
+}
+func (i historyItem) Title() string {
+	e := i.event
+	switch e.Kind {
+	case models.EventCreated:
+		if e.NewValue != "" {
+			return "Created in " + e.NewValue
+		}
+		return "Created"
+	case models.EventMoved:
+		if e.Field == "board" {
+			return fmt.Sprintf("Sent %s → %s", e.OldValue, e.NewValue)
+		}
+		return fmt.Sprintf("Moved %s → %s", e.OldValue, e.NewValue)
+	case models.EventPriority:
+		return fmt.Sprintf("Priority %s → %s", priorityOf(e.OldValue), priorityOf(e.NewValue))
+	case models.EventEdited:
+		if e.OldValue == "" {
+			return fmt.Sprintf("Set %s to %q", e.Field, e.NewValue)
+		}
+		if e.NewValue == "" {
+			return fmt.Sprintf("Cleared %s (was %q)", e.Field, e.OldValue)
+		}
+		return fmt.Sprintf("Changed %s %q → %q", e.Field, e.OldValue, e.NewValue)
+	case models.EventDeleted:
+		return "Moved to trash"
+	case models.EventRestored:
+		return "Restored from trash"
+	}
+	return e.Kind
+}
+
+type historyPane struct {
+	list list.Model
+}
+
+// openHistory shows the history of the selected task, newest first.
+func (m *Model) openHistory(task models.Task) error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	events, err := m.taskRepo.GetEvents(ctx, task.Id)
+	if err != nil {
+		return err
+	}
+
+	items := make([]list.Item, len(events))
+	for i := range events {
+		items[len(events)-1-i] = historyItem{events[i]}
+	}
+	lm := list.New(items, createListDelegate(), 0, 0)
+	lm.Title = "History: " + task.Title()
+	lm = styleListModel(lm)
+
+	m.history = historyPane{list: lm}
+	m.resizeHistory()
+	m.mode = History
+	return nil
+}
+
+func (m *Model) resizeHistory() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.history.list.SetSize(m.width-horizontal-2, m.height-17-vertical)
+}
+
+func handleHistory(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if m.history.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.history.list, cmd = m.history.list.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc", "q", "H":
+		m.mode = Normal
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.history.list, cmd = m.history.list.Update(msg)
+	return m, cmd
+}
+
+func (m Model) historyView() string {
+	help := "\n↑ ↓ to scroll, / to filter, esc to go back\n"
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.history.list.View()) + help
+}
+
+// ========= END HISTORY SECTION =========
@@ -0,0 +1,84 @@
+// Package config loads the user's kanban settings from a JSON file in the
+// platform config directory.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+
+	gap "github.com/muesli/go-app-paths"
+)
+
+// Storage backends selectable with the "backend" setting.
+const (
+    BackendSQLite = "sqlite"
+    BackendMemory = "memory"
+    BackendJSON   = "json"
+)
+
+type Config struct {
+    // Backend selects where boards are stored: "sqlite" (default),
+    // "memory" (nothing is saved) or "json" (a human-readable file).
+    Backend string `json:"backend"`
+
+    // JSONPath overrides the file used by the json backend. Defaults to
+    // kanban.json in the data directory.
+    JSONPath string `json:"json_path,omitempty"`
+
+    // User is who the My Tasks view looks for in task assignees, and who
+    // tasks are assigned to from the board. Defaults to the login name.
+    User string `json:"user,omitempty"`
+
+    // TrashRetentionDays is how long deleted tasks stay in the trash before
+    // they are purged on startup. Zero or less keeps them forever.
+    TrashRetentionDays int `json:"trash_retention_days"`
+}
+
+// Default returns the settings used when no config file exists.
+func Default() *Config {
+    return &Config{
+        Backend:            BackendSQLite,
+        User:               loginName(),
+        TrashRetentionDays: 30,
+    }
+}
+
+// DefaultPath returns the location of the config file for the given app name.
+func DefaultPath(name string) (string, error) {
+    return gap.NewScope(gap.User, name).ConfigPath("config.json")
+}
+
+// Load reads the config file at path, returning defaults when it does not
+// exist. Settings missing from the file keep their default values.
+func Load(path string) (*Config, error) {
+    cfg := Default()
+
+    content, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return cfg, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(content, cfg); err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+
+    switch cfg.Backend {
+    case BackendSQLite, BackendMemory, BackendJSON:
+    default:
+        return nil, fmt.Errorf("%s: unknown backend %q", path, cfg.Backend)
+    }
+    return cfg, nil
+}
+
+// loginName returns the name of the user running kanban, or "" if it is
+// unknown.
+func loginName() string {
+    if u, err := user.Current(); err == nil && u.Username != "" {
+        return u.Username
+    }
+    return os.Getenv("USER")
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// keepBackups is how many automatic backups are kept per database before
+// the oldest are pruned.
+const keepBackups = 5
+
+// backupPagesPerStep is how many pages are copied between checks for
+// cancellation. Small steps let writers interleave with a running backup.
+const backupPagesPerStep = 128
+
+// ErrNotKanbanDB is returned when restoring from a file that is not a
+// kanban database.
+var ErrNotKanbanDB = errors.New("not a kanban database")
+
+// copyDatabase copies the main database of src over dst using SQLite's
+// online backup API, which is safe while other connections are reading or
+// writing either side.
+func copyDatabase(ctx context.Context, dst, src *sql.DB) error {
+    dstConn, err := dst.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer dstConn.Close()
+
+    srcConn, err := src.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer srcConn.Close()
+
+    return dstConn.Raw(func(dstDriver any) error {
+        return srcConn.Raw(func(srcDriver any) error {
+            backup, err := dstDriver.(*sqlite3.SQLiteConn).Backup("main", srcDriver.(*sqlite3.SQLiteConn), "main")
+            if err != nil {
+                return err
+            }
+            for {
+                done, err := backup.Step(backupPagesPerStep)
+                if err != nil {
+                    backup.Close()
+                    return err
+                }
+                if done {
+                    return backup.Finish()
+                }
+                if err := ctx.Err(); err != nil {
+                    backup.Close()
+                    return err
+                }
+            }
+        })
+    })
+}
+
+// Backup writes a consistent copy of the database to dest. The copy is
+// written next to dest first and renamed into place, so an interrupted
+// backup never leaves a truncated file behind.
+func (tdb *TaskDB) Backup(ctx context.Context, dest string) error {
+    if err := initDataDir(filepath.Dir(dest)); err != nil {
+        return err
+    }
+    tmp := dest + ".tmp"
+    os.Remove(tmp)
+
+    dst, err := sql.Open("sqlite3", tmp)
+    if err != nil {
+        return err
+    }
+    err = copyDatabase(ctx, dst, tdb.db)
+    if err == nil {
+        // The copy inherits WAL mode; a backup should be a single file
+        _, err = dst.ExecContext(ctx, `PRAGMA journal_mode=DELETE`)
+    }
+    if cerr := dst.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    return os.Rename(tmp, dest)
+}
+
+// BackupDir is where automatic backups of the database are kept.
+func (tdb *TaskDB) BackupDir() string {
+    return filepath.Join(filepath.Dir(tdb.path), "backups")
+}
+
+// rotateBackup takes an automatic backup tagged with label and prunes all
+// but the newest keepBackups automatic backups of this database.
+func (tdb *TaskDB) rotateBackup(ctx context.Context, label string) (string, error) {
+    base := strings.TrimSuffix(filepath.Base(tdb.path), filepath.Ext(tdb.path))
+    dest, err := reserveBackup(tdb.BackupDir(), fmt.Sprintf("%s-%s-%s", base, label, time.Now().Format("20060102-150405")))
+    if err != nil {
+        return "", err
+    }
+    if err := tdb.Backup(ctx, dest); err != nil {
+        os.Remove(dest)
+        return "", err
+    }
+
+    matches, err := filepath.Glob(filepath.Join(tdb.BackupDir(), base+"-*.db"))
+    if err != nil {
+        return dest, err
+    }
+    type backupFile struct {
+        path    string
+        modTime time.Time
+    }
+    var files []backupFile
+    for _, path := range matches {
+        if info, err := os.Stat(path); err == nil {
+            files = append(files, backupFile{path, info.ModTime()})
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+    for i := keepBackups; i < len(files); i++ {
+        os.Remove(files[i].path)
+    }
+    return dest, nil
+}
+
+// reserveBackup creates an empty file in dir named name.db, or name-2.db
+// and so on if that is taken, for a backup to be renamed over. Names only
+// go down to the second, so backups taken in the same second, by this
+// process or another one, would otherwise replace each other.
+func reserveBackup(dir, name string) (string, error) {
+    if err := initDataDir(dir); err != nil {
+        return "", err
+    }
+    for n := 1; ; n++ {
+        path := filepath.Join(dir, name+".db")
+        if n > 1 {
+            path = filepath.Join(dir, fmt.Sprintf("%s-%d.db", name, n))
+        }
+        f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o660)
+        if errors.Is(err, os.ErrExist) {
+            continue
+        }
+        if err != nil {
+            return "", err
+        }
+        return path, f.Close()
+    }
+}
+
+// ValidateBackup checks that path is an intact kanban database this binary
+// can open, returning its schema version.
+func ValidateBackup(ctx context.Context, path string) (int, error) {
+    if _, err := os.Stat(path); err != nil {
+        return 0, err
+    }
+    src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+    if err != nil {
+        return 0, err
+    }
+    defer src.Close()
+
+    var result string
+    if err := src.QueryRowContext(ctx, `PRAGMA quick_check`).Scan(&result); err != nil {
+        return 0, fmt.Errorf("%s: %w", path, err)
+    }
+    if result != "ok" {
+        return 0, fmt.Errorf("%s: integrity check failed: %s", path, result)
+    }
+
+    var version int
+    err = src.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+    if err != nil {
+        return 0, fmt.Errorf("%s: %w", path, ErrNotKanbanDB)
+    }
+    if latest := LatestSchemaVersion(); version > latest {
+        return 0, fmt.Errorf("%s: %w (backup is at version %d, latest known is %d)", path, ErrSchemaTooNew, version, latest)
+    }
+    return version, nil
+}
+
+// Restore replaces the contents of the database with the backup at src.
+// The backup is validated first and the current contents are saved as an
+// automatic backup, whose path is returned. A backup from an older version
+// is migrated after it is copied in.
+func (tdb *TaskDB) Restore(ctx context.Context, src string) (string, error) {
+    if _, err := ValidateBackup(ctx, src); err != nil {
+        return "", err
+    }
+
+    saved, err := tdb.rotateBackup(ctx, "pre-restore")
+    if err != nil {
+        return "", fmt.Errorf("saving current database: %w", err)
+    }
+
+    srcDB, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+    if err != nil {
+        return saved, err
+    }
+    defer srcDB.Close()
+
+    if err := copyDatabase(ctx, tdb.db, srcDB); err != nil {
+        return saved, err
+    }
+    if err := tdb.migrate(); err != nil {
+        return saved, err
+    }
+    return saved, tdb.ensureSearchIndex()
+}
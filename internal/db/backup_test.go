@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRotateBackupSameSecond(t *testing.T) {
+    tdb, err := Open(t.TempDir() + "/kanban.db")
+    if err != nil {
+        t.Fatalf("open: %v", err)
+    }
+    defer tdb.Close()
+
+    // Backups taken within one second share a timestamp
+    seen := make(map[string]bool)
+    for i := 0; i < 3; i++ {
+        path, err := tdb.rotateBackup(context.Background(), "test")
+        if err != nil {
+            t.Fatalf("backup %d: %v", i, err)
+        }
+        if seen[path] {
+            t.Fatalf("backup %d overwrote %s", i, path)
+        }
+        seen[path] = true
+        if _, err := ValidateBackup(context.Background(), path); err != nil {
+            t.Errorf("backup %d: %v", i, err)
+        }
+    }
+}
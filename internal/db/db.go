@@ -1,18 +1,25 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"kanban/internal/models"
+
 	_ "github.com/mattn/go-sqlite3"
 	gap "github.com/muesli/go-app-paths"
 )
 
 type TaskDB struct {
-   db *sql.DB
-	 dataDir string
+    db   *sql.DB
+    path string // The database file
+
+    // Connection and last data_version seen by Changed
+    watchConn   *sql.Conn
+    dataVersion int64
 }
 
 func initDataDir(path string) error {
@@ -48,92 +55,102 @@ func setupDataPath(name string) string {
 	return taskDir
 }
 
+// DataDir returns the per-user data directory for the given app name,
+// creating it if needed.
+func DataDir(name string) string {
+	return setupDataPath(name)
+}
+
+// openDB opens the database with foreign key enforcement turned on. SQLite
+// only honours ON DELETE CASCADE when the pragma is set on every connection,
+// so it is passed through the DSN rather than executed once. The busy timeout
+// makes a locked database wait briefly instead of failing outright; callers
+// bound the total wait with their context. WAL lets another process read
+// the board while this one writes to it.
 func openDB(db_path string) (*TaskDB, error) {
-	db, err := sql.Open("sqlite3", db_path)
+	db, err := sql.Open("sqlite3", db_path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
 	if err != nil {
 		return nil, err
 	}
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
-	return &TaskDB{db, db_path}, nil
+	return &TaskDB{db: db, path: db_path}, nil
+}
+
+// DefaultPath returns the database file used when no other location is
+// configured.
+func DefaultPath(name string) string {
+	return filepath.Join(setupDataPath(name), fmt.Sprintf("%s.db", name))
 }
 
 func NewDB(name string) (*TaskDB, error) {
-	taskDir := setupDataPath(name)
-	db_path := filepath.Join(taskDir, fmt.Sprintf("%s.db", name))
+	return Open(DefaultPath(name))
+}
+
+// Open opens (creating if needed) the database at db_path and brings its
+// schema up to date.
+func Open(db_path string) (*TaskDB, error) {
+	if err := initDataDir(filepath.Dir(db_path)); err != nil {
+		return nil, err
+	}
 	db, err := openDB(db_path)
 	if err != nil {
 		return nil, err
 	}
 
-    // Create boards table
-    sqlStmt := `
-    CREATE TABLE IF NOT EXISTS boards (
-        id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-        title TEXT NOT NULL,
-        description TEXT,
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-    `
-    if _, err := db.db.Exec(sqlStmt); err != nil {
+    if err := db.migrate(); err != nil {
+        db.db.Close()
         return nil, err
     }
-
-    // Create status_columns table
-    sqlStmt = `
-    CREATE TABLE IF NOT EXISTS status_columns (
-        id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-        board_id INTEGER NOT NULL,
-        name TEXT NOT NULL,
-        position INTEGER NOT NULL,
-        color TEXT DEFAULT '',
-        FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE
-    );
-    `
-    if _, err := db.db.Exec(sqlStmt); err != nil {
+    if err := db.ensureSearchIndex(); err != nil {
+        db.db.Close()
         return nil, err
     }
 
-    // Create tasks table
-    sqlStmt = `
-    CREATE TABLE IF NOT EXISTS tasks (
-        id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-        board_id INTEGER NOT NULL,
-        status_column_id INTEGER NOT NULL,
-        title TEXT NOT NULL,
-        description TEXT,
-        position INTEGER NOT NULL DEFAULT 0,
-        priority INTEGER NOT NULL DEFAULT 1,
-        due_date DATETIME,
-        assignee TEXT,
-        tags TEXT,
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE,
-        FOREIGN KEY (status_column_id) REFERENCES status_columns(id) ON DELETE CASCADE
-    );
-    `
-    if _, err := db.db.Exec(sqlStmt); err != nil {
+    return db, nil
+}
+
+// Path returns the file the database was opened from.
+func (tdb *TaskDB) Path() string {
+    return tdb.path
+}
+
+// OpenReadOnly opens an existing database without write access. Nothing is
+// migrated or repaired, so the database must already be at
+// LatestSchemaVersion.
+func OpenReadOnly(db_path string) (*TaskDB, error) {
+    if _, err := os.Stat(db_path); err != nil {
         return nil, err
     }
-
-    // Create indexes for better performance
-    indexes := []string{
-        "CREATE INDEX IF NOT EXISTS idx_status_columns_board_id ON status_columns(board_id);",
-        "CREATE INDEX IF NOT EXISTS idx_tasks_board_id ON tasks(board_id);",
-        "CREATE INDEX IF NOT EXISTS idx_tasks_status_column_id ON tasks(status_column_id);",
-        "CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks(status_column_id, position);",
+    db, err := sql.Open("sqlite3", "file:"+db_path+"?mode=ro&_busy_timeout=5000")
+    if err != nil {
+        return nil, err
     }
+    tdb := &TaskDB{db: db, path: db_path}
 
-    for _, index := range indexes {
-        if _, err := db.db.Exec(index); err != nil {
-            return nil, err
-        }
+    version, err := tdb.SchemaVersion()
+    if err != nil {
+        db.Close()
+        return nil, fmt.Errorf("%s: %w", db_path, ErrNotKanbanDB)
     }
+    latest := LatestSchemaVersion()
+    if version > latest {
+        db.Close()
+        return nil, fmt.Errorf("%w (database is at version %d, latest known is %d)", ErrSchemaTooNew, version, latest)
+    }
+    if version < latest {
+        db.Close()
+        return nil, fmt.Errorf("%s needs upgrading from schema version %d to %d; open it once without --readonly", db_path, version, latest)
+    }
+    return tdb, nil
+}
 
-    return db, nil
+func (tdb *TaskDB) Close() error {
+    if tdb.watchConn != nil {
+        tdb.watchConn.Close()
+    }
+    return tdb.db.Close()
 }
 
 // Implement models.DBInterface methods
@@ -148,3 +165,80 @@ func (tdb *TaskDB) QueryRow(query string, args ...interface{}) *sql.Row {
 func (tdb *TaskDB) Exec(query string, args ...interface{}) (sql.Result, error) {
     return tdb.db.Exec(query, args...)
 }
+
+func (tdb *TaskDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+    return tdb.db.QueryContext(ctx, query, args...)
+}
+
+func (tdb *TaskDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+    return tdb.db.QueryRowContext(ctx, query, args...)
+}
+
+func (tdb *TaskDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+    return tdb.db.ExecContext(ctx, query, args...)
+}
+
+// WithTx runs fn inside a single database transaction. The transaction is
+// committed when fn returns nil and rolled back otherwise.
+func (tdb *TaskDB) WithTx(ctx context.Context, fn func(tx models.DBInterface) error) error {
+    tx, err := tdb.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := fn(&txDB{tx: tx}); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// txDB adapts a *sql.Tx to models.DBInterface so repositories can run inside
+// a transaction unchanged.
+type txDB struct {
+    tx        *sql.Tx
+    savepoint int
+}
+
+func (t *txDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+    return t.tx.Query(query, args...)
+}
+
+func (t *txDB) QueryRow(query string, args ...interface{}) *sql.Row {
+    return t.tx.QueryRow(query, args...)
+}
+
+func (t *txDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+    return t.tx.Exec(query, args...)
+}
+
+func (t *txDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+    return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *txDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+    return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (t *txDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+    return t.tx.ExecContext(ctx, query, args...)
+}
+
+// WithTx nests fn inside the running transaction using a savepoint, so a
+// repository method that is atomic on its own stays atomic when composed.
+func (t *txDB) WithTx(ctx context.Context, fn func(tx models.DBInterface) error) error {
+    t.savepoint++
+    name := fmt.Sprintf("sp_%d", t.savepoint)
+    defer func() { t.savepoint-- }()
+
+    if _, err := t.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+        return err
+    }
+    if err := fn(t); err != nil {
+        t.tx.Exec("ROLLBACK TO " + name)
+        t.tx.Exec("RELEASE " + name)
+        return err
+    }
+    _, err := t.tx.ExecContext(ctx, "RELEASE "+name)
+    return err
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// OrphanColumn is a status column whose board no longer exists.
+type OrphanColumn struct {
+    Id      int64
+    BoardId int64
+    Name    string
+}
+
+// OrphanTask is a task whose board or column no longer exists, or whose
+// column belongs to a different board than the task itself.
+type OrphanTask struct {
+    Id             int64
+    BoardId        int64
+    StatusColumnId int64
+    Title          string
+}
+
+// OrphanReport is the result of scanning a database for dangling rows left
+// behind before foreign keys were enforced.
+type OrphanReport struct {
+    Columns []OrphanColumn
+    Tasks   []OrphanTask
+}
+
+func (r *OrphanReport) Empty() bool {
+    return len(r.Columns) == 0 && len(r.Tasks) == 0
+}
+
+// FindOrphans scans the database for columns and tasks that reference
+// missing parents.
+func (tdb *TaskDB) FindOrphans(ctx context.Context) (*OrphanReport, error) {
+    report := &OrphanReport{}
+
+    rows, err := tdb.db.QueryContext(ctx, `
+        SELECT c.id, c.board_id, c.name
+        FROM status_columns c
+        LEFT JOIN boards b ON b.id = c.board_id
+        WHERE b.id IS NULL
+        ORDER BY c.id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var c OrphanColumn
+        if err := rows.Scan(&c.Id, &c.BoardId, &c.Name); err != nil {
+            return nil, err
+        }
+        report.Columns = append(report.Columns, c)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    rows, err = tdb.db.QueryContext(ctx, `
+        SELECT t.id, t.board_id, t.status_column_id, t.title
+        FROM tasks t
+        LEFT JOIN status_columns c ON c.id = t.status_column_id
+        LEFT JOIN boards b ON b.id = t.board_id
+        WHERE c.id IS NULL OR b.id IS NULL OR c.board_id != t.board_id
+        ORDER BY t.id
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var t OrphanTask
+        if err := rows.Scan(&t.Id, &t.BoardId, &t.StatusColumnId, &t.Title); err != nil {
+            return nil, err
+        }
+        report.Tasks = append(report.Tasks, t)
+    }
+    return report, rows.Err()
+}
+
+// DeleteOrphans removes every orphaned column and task in a single
+// transaction.
+func (tdb *TaskDB) DeleteOrphans(ctx context.Context, report *OrphanReport) error {
+    tx, err := tdb.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    for _, t := range report.Tasks {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, t.Id); err != nil {
+            return err
+        }
+    }
+    for _, c := range report.Columns {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM status_columns WHERE id = ?`, c.Id); err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// ReassignOrphans repairs orphaned rows instead of deleting them. Orphaned
+// columns are appended to boardId along with their tasks. Orphaned tasks are
+// attached to their column's board when the column still exists, otherwise to
+// the first column of their own board, falling back to the first column of
+// boardId when their board is gone too.
+func (tdb *TaskDB) ReassignOrphans(ctx context.Context, report *OrphanReport, boardId int64) error {
+    tx, err := tdb.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var nextPosition int
+    err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(position) + 1, 0) FROM status_columns WHERE board_id = ?`, boardId).Scan(&nextPosition)
+    if err != nil {
+        return err
+    }
+    for _, c := range report.Columns {
+        if _, err := tx.ExecContext(ctx, `UPDATE status_columns SET board_id = ?, position = ? WHERE id = ?`, boardId, nextPosition, c.Id); err != nil {
+            return err
+        }
+        nextPosition++
+    }
+
+    for _, t := range report.Tasks {
+        var columnBoardId int64
+        err := tx.QueryRowContext(ctx, `SELECT board_id FROM status_columns WHERE id = ?`, t.StatusColumnId).Scan(&columnBoardId)
+        switch {
+        case err == nil:
+            _, err = tx.ExecContext(ctx, `UPDATE tasks SET board_id = ? WHERE id = ?`, columnBoardId, t.Id)
+        case err == sql.ErrNoRows:
+            var targetBoard, targetColumn int64
+            targetBoard, targetColumn, err = firstColumn(ctx, tx, t.BoardId)
+            if err == sql.ErrNoRows {
+                targetBoard, targetColumn, err = firstColumn(ctx, tx, boardId)
+                if err == sql.ErrNoRows {
+                    return fmt.Errorf("board %d has no columns to reassign task %d to", boardId, t.Id)
+                }
+            }
+            if err != nil {
+                return err
+            }
+            _, err = tx.ExecContext(ctx, `UPDATE tasks SET board_id = ?, status_column_id = ? WHERE id = ?`, targetBoard, targetColumn, t.Id)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// firstColumn returns the left-most column of an existing board.
+func firstColumn(ctx context.Context, tx *sql.Tx, boardId int64) (int64, int64, error) {
+    var columnId int64
+    err := tx.QueryRowContext(ctx, `
+        SELECT c.id FROM status_columns c
+        JOIN boards b ON b.id = c.board_id
+        WHERE c.board_id = ?
+        ORDER BY c.position LIMIT 1
+    `, boardId).Scan(&columnId)
+    return boardId, columnId, err
+}
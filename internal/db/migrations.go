@@ -0,0 +1,327 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"kanban/internal/models"
+)
+
+// ErrSchemaTooNew is returned when a database has been migrated by a newer
+// version of the binary than the one trying to open it.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of kanban supports")
+
+// migration is a single, ordered schema change. Every migration runs in its
+// own transaction together with the bookkeeping row in schema_migrations, so
+// a failure leaves the database at the previous version.
+type migration struct {
+    version int
+    name    string
+    up      func(tx *sql.Tx) error
+}
+
+// migrations is the full, ordered schema history. Never edit or reorder an
+// entry that has been released; append a new one instead.
+var migrations = []migration{
+    {version: 1, name: "initial schema", up: migrateInitialSchema},
+    {version: 2, name: "soft delete tasks", up: migrateSoftDelete},
+    {version: 3, name: "task events", up: migrateTaskEvents},
+    {version: 4, name: "task versions", up: migrateTaskVersions},
+    {version: 5, name: "task ranks", up: migrateTaskRanks},
+    {version: 6, name: "tags", up: migrateTags},
+    {version: 7, name: "column sort", up: migrateColumnSort},
+}
+
+// LatestSchemaVersion returns the schema version this binary writes.
+func LatestSchemaVersion() int {
+    return migrations[len(migrations)-1].version
+}
+
+func execAll(tx *sql.Tx, stmts ...string) error {
+    for _, stmt := range stmts {
+        if _, err := tx.Exec(stmt); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// SchemaVersion returns the highest migration applied to the database, or 0
+// for a database that has never been migrated.
+func (tdb *TaskDB) SchemaVersion() (int, error) {
+    var version int
+    err := tdb.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+    return version, err
+}
+
+// migrate brings the database up to LatestSchemaVersion, refusing to touch a
+// database that was written by a newer binary.
+func (tdb *TaskDB) migrate() error {
+    sqlStmt := `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER NOT NULL PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+    `
+    if _, err := tdb.db.Exec(sqlStmt); err != nil {
+        return err
+    }
+
+    current, err := tdb.SchemaVersion()
+    if err != nil {
+        return err
+    }
+    if latest := LatestSchemaVersion(); current > latest {
+        return fmt.Errorf("%w (database is at version %d, latest known is %d)", ErrSchemaTooNew, current, latest)
+    }
+
+    // Keep a copy of existing data before changing its schema. Databases
+    // created before schema_migrations existed are at version 0 but still
+    // hold boards worth saving.
+    var legacyTables int
+    err = tdb.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'boards'`).Scan(&legacyTables)
+    if err != nil {
+        return err
+    }
+    if (current > 0 || legacyTables > 0) && current < LatestSchemaVersion() {
+        if _, err := tdb.rotateBackup(context.Background(), fmt.Sprintf("v%d", current)); err != nil {
+            return fmt.Errorf("backing up before migration: %w", err)
+        }
+    }
+
+    for _, m := range migrations {
+        if m.version <= current {
+            continue
+        }
+        if err := tdb.applyMigration(m); err != nil {
+            return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+        }
+    }
+    return nil
+}
+
+func (tdb *TaskDB) applyMigration(m migration) error {
+    tx, err := tdb.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := m.up(tx); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// migrateInitialSchema creates the original tables. It uses IF NOT EXISTS so
+// databases created before schema_migrations existed are adopted as version 1.
+func migrateInitialSchema(tx *sql.Tx) error {
+    return execAll(tx,
+        `CREATE TABLE IF NOT EXISTS boards (
+            id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+            title TEXT NOT NULL,
+            description TEXT,
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+            updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `CREATE TABLE IF NOT EXISTS status_columns (
+            id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+            board_id INTEGER NOT NULL,
+            name TEXT NOT NULL,
+            position INTEGER NOT NULL,
+            color TEXT DEFAULT '',
+            FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE
+        );`,
+        `CREATE TABLE IF NOT EXISTS tasks (
+            id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+            board_id INTEGER NOT NULL,
+            status_column_id INTEGER NOT NULL,
+            title TEXT NOT NULL,
+            description TEXT,
+            position INTEGER NOT NULL DEFAULT 0,
+            priority INTEGER NOT NULL DEFAULT 1,
+            due_date DATETIME,
+            assignee TEXT,
+            tags TEXT,
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+            updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE,
+            FOREIGN KEY (status_column_id) REFERENCES status_columns(id) ON DELETE CASCADE
+        );`,
+        // Indexes for better performance
+        "CREATE INDEX IF NOT EXISTS idx_status_columns_board_id ON status_columns(board_id);",
+        "CREATE INDEX IF NOT EXISTS idx_tasks_board_id ON tasks(board_id);",
+        "CREATE INDEX IF NOT EXISTS idx_tasks_status_column_id ON tasks(status_column_id);",
+        "CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks(status_column_id, position);",
+    )
+}
+
+// migrateSoftDelete lets tasks be moved to a trash instead of being deleted.
+func migrateSoftDelete(tx *sql.Tx) error {
+    return execAll(tx,
+        "ALTER TABLE tasks ADD COLUMN deleted_at DATETIME;",
+        "CREATE INDEX idx_tasks_deleted_at ON tasks(board_id, deleted_at);",
+    )
+}
+
+// migrateTaskEvents adds the history of changes to each task.
+func migrateTaskEvents(tx *sql.Tx) error {
+    return execAll(tx,
+        `CREATE TABLE task_events (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            task_id INTEGER NOT NULL,
+            board_id INTEGER NOT NULL,
+            kind TEXT NOT NULL,
+            field TEXT NOT NULL DEFAULT '',
+            old_value TEXT NOT NULL DEFAULT '',
+            new_value TEXT NOT NULL DEFAULT '',
+            created_at DATETIME NOT NULL,
+            FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+        );`,
+        "CREATE INDEX idx_task_events_task_id ON task_events(task_id, created_at);",
+        "CREATE INDEX idx_task_events_board_id ON task_events(board_id, created_at);",
+    )
+}
+
+// migrateTaskVersions adds the row version used to detect conflicting
+// updates.
+func migrateTaskVersions(tx *sql.Tx) error {
+    return execAll(tx, "ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;")
+}
+
+// migrateTaskRanks replaces integer positions, which every insert had to
+// shift, with ranks that order the tasks of a column as strings. Existing
+// tasks are ranked in their current order, trashed ones included so they
+// return to their place when restored.
+func migrateTaskRanks(tx *sql.Tx) error {
+    err := execAll(tx,
+        "ALTER TABLE tasks ADD COLUMN rank TEXT NOT NULL DEFAULT '';",
+        "CREATE INDEX idx_tasks_rank ON tasks(status_column_id, rank);",
+    )
+    if err != nil {
+        return err
+    }
+
+    rows, err := tx.Query(`SELECT id, status_column_id FROM tasks ORDER BY status_column_id, position, id`)
+    if err != nil {
+        return err
+    }
+    columns := make(map[int64][]int64)
+    var order []int64
+    for rows.Next() {
+        var id, columnId int64
+        if err := rows.Scan(&id, &columnId); err != nil {
+            rows.Close()
+            return err
+        }
+        if _, ok := columns[columnId]; !ok {
+            order = append(order, columnId)
+        }
+        columns[columnId] = append(columns[columnId], id)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    for _, columnId := range order {
+        ids := columns[columnId]
+        for i, rank := range models.SpreadRanks(len(ids)) {
+            if _, err := tx.Exec(`UPDATE tasks SET rank = ? WHERE id = ?`, rank, ids[i]); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// migrateTags moves tags from the free-form tasks.tags strings into a table
+// of each board's tags and a table linking them to tasks. tasks.tags stays,
+// rewritten as the comma-separated names, for search and history.
+func migrateTags(tx *sql.Tx) error {
+    err := execAll(tx,
+        `CREATE TABLE tags (
+            id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+            board_id INTEGER NOT NULL,
+            name TEXT NOT NULL COLLATE NOCASE,
+            color TEXT NOT NULL DEFAULT '',
+            UNIQUE (board_id, name),
+            FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE
+        );`,
+        `CREATE TABLE task_tags (
+            task_id INTEGER NOT NULL,
+            tag_id INTEGER NOT NULL,
+            PRIMARY KEY (task_id, tag_id),
+            FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+            FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+        );`,
+        "CREATE INDEX idx_task_tags_tag_id ON task_tags(tag_id);",
+    )
+    if err != nil {
+        return err
+    }
+
+    type tagged struct {
+        id, boardId int64
+        tags        string
+    }
+    // Orphaned tasks, see FindOrphans, have no board to keep tags in and
+    // are left out
+    rows, err := tx.Query(`
+        SELECT t.id, t.board_id, t.tags FROM tasks t
+        JOIN boards b ON b.id = t.board_id
+        WHERE t.tags IS NOT NULL AND t.tags != ''
+    `)
+    if err != nil {
+        return err
+    }
+    var tasks []tagged
+    for rows.Next() {
+        var t tagged
+        if err := rows.Scan(&t.id, &t.boardId, &t.tags); err != nil {
+            rows.Close()
+            return err
+        }
+        tasks = append(tasks, t)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    for _, t := range tasks {
+        names := models.ParseTags(t.tags)
+        for i, name := range names {
+            if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (board_id, name) VALUES (?, ?)`, t.boardId, name); err != nil {
+                return err
+            }
+            // Spell the tag as the board's first task using it did
+            var id int64
+            err := tx.QueryRow(`SELECT id, name FROM tags WHERE board_id = ? AND name = ?`, t.boardId, name).Scan(&id, &names[i])
+            if err != nil {
+                return err
+            }
+            if _, err := tx.Exec(`INSERT OR IGNORE INTO task_tags (task_id, tag_id) VALUES (?, ?)`, t.id, id); err != nil {
+                return err
+            }
+        }
+        if tags := models.FormatTags(names); tags != t.tags {
+            if _, err := tx.Exec(`UPDATE tasks SET tags = ? WHERE id = ?`, tags, t.id); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// migrateColumnSort lets a column order its tasks by priority instead of
+// by hand.
+func migrateColumnSort(tx *sql.Tx) error {
+    return execAll(tx, "ALTER TABLE status_columns ADD COLUMN sort TEXT NOT NULL DEFAULT '';")
+}
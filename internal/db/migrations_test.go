@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kanban/internal/models"
+)
+
+// legacyDB writes a database as kanban wrote it before schema_migrations
+// existed: the initial tables, with tasks ordered by position and tags kept
+// as free-form strings.
+func legacyDB(t *testing.T, path string) {
+    t.Helper()
+    raw, err := sql.Open("sqlite3", path)
+    if err != nil {
+        t.Fatalf("open: %v", err)
+    }
+    defer raw.Close()
+
+    tx, err := raw.Begin()
+    if err != nil {
+        t.Fatalf("begin: %v", err)
+    }
+    defer tx.Rollback()
+    if err := migrateInitialSchema(tx); err != nil {
+        t.Fatalf("initial schema: %v", err)
+    }
+    err = execAll(tx,
+        `INSERT INTO boards (id, title, description) VALUES (1, 'Legacy', '')`,
+        `INSERT INTO status_columns (id, board_id, name, position) VALUES (1, 1, 'Todo', 0), (2, 1, 'Done', 1)`,
+        `INSERT INTO tasks (id, board_id, status_column_id, title, description, position, assignee, tags) VALUES
+            (1, 1, 1, 'third', '', 2, '', 'bug'),
+            (2, 1, 1, 'first', '', 0, '', 'Bug, ui'),
+            (3, 1, 1, 'second', '', 1, '', ''),
+            (4, 1, 2, 'done', '', 0, '', '')`,
+    )
+    if err != nil {
+        t.Fatalf("insert: %v", err)
+    }
+    if err := tx.Commit(); err != nil {
+        t.Fatalf("commit: %v", err)
+    }
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "kanban.db")
+    legacyDB(t, path)
+
+    tdb, err := Open(path)
+    if err != nil {
+        t.Fatalf("open legacy database: %v", err)
+    }
+    defer tdb.Close()
+
+    version, err := tdb.SchemaVersion()
+    if err != nil {
+        t.Fatalf("schema version: %v", err)
+    }
+    if version != LatestSchemaVersion() {
+        t.Errorf("schema at version %d, want %d", version, LatestSchemaVersion())
+    }
+    var applied []int
+    rows, err := tdb.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+    if err != nil {
+        t.Fatalf("read migrations: %v", err)
+    }
+    for rows.Next() {
+        var v int
+        if err := rows.Scan(&v); err != nil {
+            t.Fatalf("read migrations: %v", err)
+        }
+        applied = append(applied, v)
+    }
+    rows.Close()
+    if want := []int{1, 2, 3, 4, 5, 6, 7}; !slices.Equal(applied, want) {
+        t.Errorf("applied migrations %v, want %v", applied, want)
+    }
+
+    // The data was backed up before its schema changed
+    backups, _ := filepath.Glob(filepath.Join(tdb.BackupDir(), "kanban-v0-*.db"))
+    if len(backups) != 1 {
+        t.Errorf("found %d backups of the legacy database, want 1", len(backups))
+    } else if _, err := os.Stat(backups[0]); err != nil {
+        t.Errorf("backup: %v", err)
+    }
+
+    ctx := context.Background()
+    stores := models.NewSQLStores(tdb)
+    tasks, err := stores.Tasks.GetByColumnId(ctx, 1)
+    if err != nil {
+        t.Fatalf("get tasks: %v", err)
+    }
+    var titles []string
+    for _, task := range tasks {
+        titles = append(titles, task.Title())
+        if task.Version != 1 || task.DeletedAt != nil || task.Rank == "" {
+            t.Errorf("%q migrated at version %d, rank %q, deleted %v", task.Title(), task.Version, task.Rank, task.DeletedAt)
+        }
+    }
+    if want := []string{"first", "second", "third"}; !slices.Equal(titles, want) {
+        t.Errorf("column ranked %q, want the old positions' order %q", titles, want)
+    }
+
+    tags, err := stores.Tags.GetByBoardId(ctx, 1)
+    if err != nil {
+        t.Fatalf("get tags: %v", err)
+    }
+    var names []string
+    for _, tag := range tags {
+        names = append(names, tag.Name)
+    }
+    if want := []string{"bug", "ui"}; !slices.Equal(names, want) {
+        t.Errorf("board has tags %q, want %q", names, want)
+    }
+    first, err := stores.Tasks.GetById(ctx, 2)
+    if err != nil {
+        t.Fatalf("get task: %v", err)
+    }
+    if want := "bug, ui"; first.Tags != want {
+        // Spelled as by the first task to use the tag
+        t.Errorf("tags of %q rewritten as %q, want %q", first.Title(), first.Tags, want)
+    }
+
+    columns, err := stores.Columns.GetByBoardId(ctx, 1)
+    if err != nil {
+        t.Fatalf("get columns: %v", err)
+    }
+    if len(columns) != 2 || columns[0].Sort != models.SortManual {
+        t.Errorf("columns migrated as %+v, want two sorted by hand", columns)
+    }
+}
@@ -0,0 +1,84 @@
+package db
+
+// The full-text index is derived data: it can always be rebuilt from the
+// tasks table. It is therefore maintained here at open time rather than by a
+// versioned migration, because FTS5 is only compiled into go-sqlite3 when
+// building with `-tags sqlite_fts5`, as the Makefile does. A binary without FTS5 drops the
+// triggers (writes would otherwise fail with "no such module"), and the next
+// binary with FTS5 recreates them and rebuilds the index.
+
+var searchTriggers = []string{
+    `CREATE TRIGGER IF NOT EXISTS tasks_fts_ai AFTER INSERT ON tasks BEGIN
+        INSERT INTO tasks_fts(rowid, title, description, tags)
+        VALUES (new.id, new.title, new.description, new.tags);
+    END;`,
+    `CREATE TRIGGER IF NOT EXISTS tasks_fts_ad AFTER DELETE ON tasks BEGIN
+        INSERT INTO tasks_fts(tasks_fts, rowid, title, description, tags)
+        VALUES ('delete', old.id, old.title, old.description, old.tags);
+    END;`,
+    `CREATE TRIGGER IF NOT EXISTS tasks_fts_au AFTER UPDATE OF title, description, tags ON tasks BEGIN
+        INSERT INTO tasks_fts(tasks_fts, rowid, title, description, tags)
+        VALUES ('delete', old.id, old.title, old.description, old.tags);
+        INSERT INTO tasks_fts(rowid, title, description, tags)
+        VALUES (new.id, new.title, new.description, new.tags);
+    END;`,
+}
+
+// SearchIndexAvailable reports whether this binary was built with FTS5.
+func (tdb *TaskDB) SearchIndexAvailable() (bool, error) {
+    var used bool
+    err := tdb.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&used)
+    return used, err
+}
+
+// ensureSearchIndex creates or repairs the tasks_fts index and its
+// triggers, or removes the triggers when FTS5 is unavailable.
+func (tdb *TaskDB) ensureSearchIndex() error {
+    available, err := tdb.SearchIndexAvailable()
+    if err != nil {
+        return err
+    }
+
+    tx, err := tdb.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if !available {
+        err := execAll(tx,
+            "DROP TRIGGER IF EXISTS tasks_fts_ai;",
+            "DROP TRIGGER IF EXISTS tasks_fts_ad;",
+            "DROP TRIGGER IF EXISTS tasks_fts_au;",
+        )
+        if err != nil {
+            return err
+        }
+        return tx.Commit()
+    }
+
+    var triggers int
+    err = tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'tasks_fts_%'`).Scan(&triggers)
+    if err != nil {
+        return err
+    }
+    if triggers == len(searchTriggers) {
+        return nil
+    }
+
+    // The index is new or missed writes while its triggers were gone
+    err = execAll(tx, `CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+        title, description, tags,
+        content='tasks', content_rowid='id'
+    );`)
+    if err != nil {
+        return err
+    }
+    if err := execAll(tx, searchTriggers...); err != nil {
+        return err
+    }
+    if err := execAll(tx, `INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild');`); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Changed reports whether the database was written by another connection
+// or process since the previous call. It reads PRAGMA data_version on a
+// connection that is held open for the purpose, because the value is only
+// comparable on the connection that reported it. The first call only takes
+// the baseline and reports no change.
+//
+// Writes made through tdb go through the other connections of its pool and
+// are reported too. The version moves once however many commits landed, so
+// it cannot tell them from another process's; callers compare what they
+// read instead. Calls must not overlap.
+func (tdb *TaskDB) Changed(ctx context.Context) (bool, error) {
+    if tdb.watchConn == nil {
+        conn, err := tdb.db.Conn(ctx)
+        if err != nil {
+            return false, err
+        }
+        tdb.watchConn = conn
+    }
+
+    var version int64
+    err := tdb.watchConn.QueryRowContext(ctx, `PRAGMA data_version`).Scan(&version)
+    if err != nil {
+        if err == sql.ErrConnDone {
+            tdb.watchConn = nil
+        }
+        return false, err
+    }
+
+    changed := tdb.dataVersion != 0 && version != tdb.dataVersion
+    tdb.dataVersion = version
+    return changed, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestChanged(t *testing.T) {
+    ctx := context.Background()
+    path := filepath.Join(t.TempDir(), "kanban.db")
+    watched, err := Open(path)
+    if err != nil {
+        t.Fatalf("open: %v", err)
+    }
+    defer watched.Close()
+    other, err := Open(path)
+    if err != nil {
+        t.Fatalf("open a second time: %v", err)
+    }
+    defer other.Close()
+
+    changed := func(step string, want bool) {
+        t.Helper()
+        got, err := watched.Changed(ctx)
+        if err != nil {
+            t.Fatalf("%s: %v", step, err)
+        }
+        if got != want {
+            t.Errorf("%s: Changed() = %v, want %v", step, got, want)
+        }
+    }
+    write := func(tdb *TaskDB, title string) {
+        t.Helper()
+        if _, err := tdb.Exec(`INSERT INTO boards (title) VALUES (?)`, title); err != nil {
+            t.Fatalf("write %q: %v", title, err)
+        }
+    }
+
+    changed("baseline", false)
+    changed("nothing written", false)
+
+    write(other, "outside")
+    changed("after an outside write", true)
+    changed("once reported", false)
+
+    // Own writes use other connections of the pool, so they show too
+    write(watched, "own")
+    changed("after an own write", true)
+
+    // Several commits between calls are one change
+    write(other, "one")
+    write(other, "two")
+    write(watched, "three")
+    changed("after several writes", true)
+    changed("once reported again", false)
+
+    // Reads change nothing
+    var boards int
+    if err := other.QueryRow(`SELECT COUNT(*) FROM boards`).Scan(&boards); err != nil {
+        t.Fatalf("read: %v", err)
+    }
+    changed("after a read", false)
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func lockFile(file *os.File) error {
+    err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+    if errors.Is(err, unix.EWOULDBLOCK) {
+        return ErrLocked
+    }
+    return err
+}
+
+func unlockFile(file *os.File) error {
+    return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset places the locked byte past the recorded process id, because
+// Windows locks are mandatory and would otherwise stop Holder reading it.
+const lockOffset = 1 << 30
+
+func lockFile(file *os.File) error {
+    overlapped := &windows.Overlapped{Offset: lockOffset}
+    flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+    err := windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, 1, 0, overlapped)
+    if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+        return ErrLocked
+    }
+    return err
+}
+
+func unlockFile(file *os.File) error {
+    overlapped := &windows.Overlapped{Offset: lockOffset}
+    return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, overlapped)
+}
@@ -0,0 +1,134 @@
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kanban/internal/models"
+)
+
+// fileStamp identifies a version of the JSON file without reading it.
+type fileStamp struct {
+    modTime time.Time
+    size    int64
+}
+
+func statFile(path string) (fileStamp, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        return fileStamp{}, err
+    }
+    return fileStamp{info.ModTime(), info.Size()}, nil
+}
+
+// OpenFile returns a store persisted to the JSON file at path, loading any
+// existing content or creating an empty file. The file is rewritten
+// atomically after every change.
+func OpenFile(path string) (*Store, error) {
+    s := &Store{path: path}
+    s.save = func(d *data) error {
+        if err := writeFile(path, d); err != nil {
+            return err
+        }
+        // Our own writes are not changes for Changed to report
+        s.stamp, _ = statFile(path)
+        return nil
+    }
+
+    if _, err := os.Stat(path); os.IsNotExist(err) {
+        if err := os.MkdirAll(filepath.Dir(path), 0o770); err != nil {
+            return nil, err
+        }
+        if err := s.save(&s.data); err != nil {
+            return nil, err
+        }
+        return s, nil
+    }
+    if err := s.load(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// OpenFileReadOnly returns a store showing the existing JSON file at path.
+// Every change fails with models.ErrReadOnly and the file is never written.
+func OpenFileReadOnly(path string) (*Store, error) {
+    s := &Store{path: path}
+    s.save = func(d *data) error { return models.ErrReadOnly }
+    if err := s.load(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// load replaces the store's contents with the file's. The caller must hold
+// the write lock or own the store exclusively.
+func (s *Store) load() error {
+    stamp, err := statFile(s.path)
+    if err != nil {
+        return err
+    }
+    content, err := os.ReadFile(s.path)
+    if err != nil {
+        return err
+    }
+    var d data
+    if err := json.Unmarshal(content, &d); err != nil {
+        return err
+    }
+    d.fillRanks()
+    d.fillTags()
+    s.data = d
+    s.stamp = stamp
+    return nil
+}
+
+// Changed reports whether the JSON file was rewritten by someone else since
+// it was last loaded or saved, and reloads it if so. Stores that are not
+// backed by a file never change underneath their users.
+func (s *Store) Changed(ctx context.Context) (bool, error) {
+    if err := ctx.Err(); err != nil {
+        return false, err
+    }
+    if s.path == "" {
+        return false, nil
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    stamp, err := statFile(s.path)
+    if err != nil {
+        return false, err
+    }
+    if stamp == s.stamp {
+        return false, nil
+    }
+    return true, s.load()
+}
+
+// writeFile writes d to a temporary file next to path and renames it into
+// place, so a crash never leaves a half-written board behind.
+func writeFile(path string, d *data) error {
+    content, err := json.MarshalIndent(d, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := tmp.Write(content); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,300 @@
+// Package memstore implements the models store interfaces in memory, with
+// optional persistence to a human-readable JSON file.
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kanban/internal/models"
+)
+
+// data is everything a Store holds. It is also the on-disk format of the
+// JSON-file backend, so field names are part of that format.
+type data struct {
+    NextBoardId  int64                 `json:"next_board_id"`
+    NextColumnId int64                 `json:"next_column_id"`
+    NextTaskId   int64                 `json:"next_task_id"`
+    NextEventId  int64                 `json:"next_event_id"`
+    NextTagId    int64                 `json:"next_tag_id"`
+    Boards       []models.Board        `json:"boards"`
+    Columns      []models.StatusColumn `json:"columns"`
+    Tasks        []models.Task         `json:"tasks"`
+    Events       []models.TaskEvent    `json:"events"`
+
+    // Tags holds each board's tags and their colors. Which tasks carry a
+    // tag is read from the tasks' Tags.
+    Tags []models.Tag `json:"tags"`
+}
+
+func (d *data) clone() data {
+    c := *d
+    c.Boards = append([]models.Board(nil), d.Boards...)
+    c.Columns = append([]models.StatusColumn(nil), d.Columns...)
+    c.Tasks = append([]models.Task(nil), d.Tasks...)
+    c.Events = append([]models.TaskEvent(nil), d.Events...)
+    c.Tags = append([]models.Tag(nil), d.Tags...)
+    return c
+}
+
+func (d *data) board(id int64) int {
+    for i := range d.Boards {
+        if d.Boards[i].Id == id {
+            return i
+        }
+    }
+    return -1
+}
+
+func (d *data) column(id int64) int {
+    for i := range d.Columns {
+        if d.Columns[i].Id == id {
+            return i
+        }
+    }
+    return -1
+}
+
+func (d *data) task(id int64) int {
+    for i := range d.Tasks {
+        if d.Tasks[i].Id == id {
+            return i
+        }
+    }
+    return -1
+}
+
+// rankLess orders tasks within a column, as the SQLite backend does.
+func rankLess(a, b *models.Task) bool {
+    if a.Rank != b.Rank {
+        return a.Rank < b.Rank
+    }
+    return a.Id < b.Id
+}
+
+// columnRanks returns the ranks of the live tasks of columnId other than
+// taskId, in order.
+func (d *data) columnRanks(columnId, taskId int64) []string {
+    tasks := filter(append([]models.Task(nil), d.Tasks...), func(t *models.Task) bool {
+        return t.StatusColumnId == columnId && t.DeletedAt == nil && t.Id != taskId
+    })
+    sort.Slice(tasks, func(i, j int) bool { return rankLess(&tasks[i], &tasks[j]) })
+    ranks := make([]string, len(tasks))
+    for i := range tasks {
+        ranks[i] = tasks[i].Rank
+    }
+    return ranks
+}
+
+// rankAt returns the rank placing a task at index among the live tasks of
+// columnId other than taskId, respreading the column first if the rank
+// would pass models.RankLimit.
+func (d *data) rankAt(columnId, taskId int64, index int) string {
+    rank := models.RankAt(d.columnRanks(columnId, taskId), index)
+    if len(rank) <= models.RankLimit {
+        return rank
+    }
+
+    // Trashed tasks are reranked too, as in the SQLite backend
+    var indexes []int
+    for i := range d.Tasks {
+        if d.Tasks[i].StatusColumnId == columnId && d.Tasks[i].Id != taskId {
+            indexes = append(indexes, i)
+        }
+    }
+    sort.SliceStable(indexes, func(a, b int) bool { return rankLess(&d.Tasks[indexes[a]], &d.Tasks[indexes[b]]) })
+    for j, rank := range models.SpreadRanks(len(indexes)) {
+        d.Tasks[indexes[j]].Rank = rank
+    }
+    return models.RankAt(d.columnRanks(columnId, taskId), index)
+}
+
+// position returns task's index among the live tasks of its column.
+func (d *data) position(task *models.Task) int {
+    position := 0
+    for i := range d.Tasks {
+        other := &d.Tasks[i]
+        if other.StatusColumnId == task.StatusColumnId && other.DeletedAt == nil && rankLess(other, task) {
+            position++
+        }
+    }
+    return position
+}
+
+// fillRanks ranks the tasks of files written before tasks had ranks,
+// keeping the order of their positions.
+func (d *data) fillRanks() {
+    columns := make(map[int64][]int)
+    for i := range d.Tasks {
+        if d.Tasks[i].Rank == "" {
+            columns[d.Tasks[i].StatusColumnId] = nil
+        }
+    }
+    for i := range d.Tasks {
+        if indexes, ok := columns[d.Tasks[i].StatusColumnId]; ok {
+            columns[d.Tasks[i].StatusColumnId] = append(indexes, i)
+        }
+    }
+    for _, indexes := range columns {
+        sort.SliceStable(indexes, func(a, b int) bool {
+            ta, tb := &d.Tasks[indexes[a]], &d.Tasks[indexes[b]]
+            if ta.Position != tb.Position {
+                return ta.Position < tb.Position
+            }
+            return ta.Id < tb.Id
+        })
+        for j, rank := range models.SpreadRanks(len(indexes)) {
+            d.Tasks[indexes[j]].Rank = rank
+        }
+    }
+}
+
+// syncTags creates the tags the tasks of a board carry that the board does
+// not have yet, and drops the ones no task carries anymore unless they have
+// a color, as the SQLite backend does on every task write.
+func (d *data) syncTags(boardId int64) {
+    carried := make(map[string]string)
+    var keys []string
+    for i := range d.Tasks {
+        if d.Tasks[i].BoardId != boardId {
+            continue
+        }
+        for _, name := range models.ParseTags(d.Tasks[i].Tags) {
+            key := strings.ToLower(name)
+            if _, ok := carried[key]; !ok {
+                carried[key] = name
+                keys = append(keys, key)
+            }
+        }
+    }
+
+    kept := make(map[string]bool)
+    d.Tags = filter(d.Tags, func(t *models.Tag) bool {
+        if t.BoardId != boardId {
+            return true
+        }
+        key := strings.ToLower(t.Name)
+        if _, ok := carried[key]; ok || t.Color != "" {
+            kept[key] = true
+            return true
+        }
+        return false
+    })
+    for _, key := range keys {
+        if !kept[key] {
+            d.NextTagId++
+            d.Tags = append(d.Tags, models.Tag{Id: d.NextTagId, BoardId: boardId, Name: carried[key]})
+        }
+    }
+}
+
+// fillTags creates the tags of files written before boards had tags.
+func (d *data) fillTags() {
+    for i := range d.Boards {
+        d.syncTags(d.Boards[i].Id)
+    }
+}
+
+// columnName resolves a column id for the task history.
+func (d *data) columnName(id int64) string {
+    if i := d.column(id); i >= 0 {
+        return d.Columns[i].Name
+    }
+    return ""
+}
+
+// boardTitle resolves a board id for the task history.
+func (d *data) boardTitle(id int64) string {
+    if i := d.board(id); i >= 0 {
+        return d.Boards[i].Title
+    }
+    return ""
+}
+
+// addEvents records events, assigning ids and timestamps.
+func (d *data) addEvents(events ...models.TaskEvent) {
+    now := time.Now()
+    for _, event := range events {
+        d.NextEventId++
+        event.Id = d.NextEventId
+        event.CreatedAt = now
+        d.Events = append(d.Events, event)
+    }
+}
+
+// dropOrphanEvents removes the history of tasks that no longer exist, as
+// the SQLite backend's cascading delete does.
+func (d *data) dropOrphanEvents() {
+    d.Events = filter(d.Events, func(e *models.TaskEvent) bool { return d.task(e.TaskId) >= 0 })
+}
+
+// Store holds boards, columns and tasks in memory. Every mutation is applied
+// to a copy first, so a failed operation (or a failed save for file-backed
+// stores) leaves the store unchanged, matching the transactional behaviour
+// of the SQLite backend.
+type Store struct {
+    mu   sync.RWMutex
+    data data
+
+    // save, when set, is called with the new state after every mutation.
+    save func(d *data) error
+
+    // path and stamp identify the backing file of file-backed stores, as
+    // of the last load or save.
+    path  string
+    stamp fileStamp
+}
+
+// New returns an empty, purely in-memory store.
+func New() *Store {
+    return &Store{}
+}
+
+// Stores returns the models stores backed by s.
+func (s *Store) Stores() models.Stores {
+    stores := models.Stores{
+        Boards:  boardStore{s},
+        Columns: columnStore{s},
+        Tasks:   taskStore{s},
+        Tags:    tagStore{s},
+    }
+    if s.path != "" {
+        stores.Watcher = s
+    }
+    return stores
+}
+
+// view runs fn with a read lock held.
+func (s *Store) view(ctx context.Context, fn func(d *data) error) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return fn(&s.data)
+}
+
+// update runs fn against a copy of the data and swaps it in only if fn and
+// the optional save both succeed.
+func (s *Store) update(ctx context.Context, fn func(d *data) error) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    next := s.data.clone()
+    if err := fn(&next); err != nil {
+        return err
+    }
+    if s.save != nil {
+        if err := s.save(&next); err != nil {
+            return err
+        }
+    }
+    s.data = next
+    return nil
+}
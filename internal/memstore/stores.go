@@ -0,0 +1,691 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kanban/internal/models"
+)
+
+// Board operations
+type boardStore struct{ s *Store }
+
+func (b boardStore) Create(ctx context.Context, board *models.Board) error {
+    return b.s.update(ctx, func(d *data) error {
+        createBoard(d, board)
+        return nil
+    })
+}
+
+func createBoard(d *data, board *models.Board) {
+    now := time.Now()
+    board.CreatedAt = now
+    board.UpdatedAt = now
+
+    d.NextBoardId++
+    board.Id = d.NextBoardId
+
+    stored := *board
+    stored.Columns = nil
+    stored.Tasks = nil
+    d.Boards = append(d.Boards, stored)
+}
+
+func (b boardStore) CreateWithColumns(ctx context.Context, board *models.Board, columns []models.StatusColumn) error {
+    return b.s.update(ctx, func(d *data) error {
+        createBoard(d, board)
+        created := make([]models.StatusColumn, len(columns))
+        for i, col := range columns {
+            col.BoardId = board.Id
+            if err := createColumn(d, &col); err != nil {
+                return err
+            }
+            created[i] = col
+        }
+        board.Columns = created
+        return nil
+    })
+}
+
+func (b boardStore) Duplicate(ctx context.Context, id int64, board *models.Board) error {
+    return b.s.update(ctx, func(d *data) error {
+        if d.board(id) < 0 {
+            return models.ErrNotFound
+        }
+        columns := columnsByBoard(d, id)
+        createBoard(d, board)
+        board.Columns = make([]models.StatusColumn, len(columns))
+        for i, col := range columns {
+            copied := col
+            copied.Id = 0
+            copied.BoardId = board.Id
+            if err := createColumn(d, &copied); err != nil {
+                return err
+            }
+            board.Columns[i] = copied
+
+            tasks := tasksWhere(d, func(t *models.Task) bool { return t.StatusColumnId == col.Id && t.DeletedAt == nil })
+            sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Position < tasks[j].Position })
+            for _, task := range tasks {
+                d.NextTaskId++
+                task.Id = d.NextTaskId
+                task.BoardId = board.Id
+                task.StatusColumnId = copied.Id
+                task.CreatedAt = board.CreatedAt
+                task.UpdatedAt = board.CreatedAt
+                task.Version = 1
+                d.Tasks = append(d.Tasks, task)
+                d.addEvents(models.TaskEvent{
+                    TaskId:   task.Id,
+                    BoardId:  board.Id,
+                    Kind:     models.EventCreated,
+                    NewValue: copied.Name,
+                })
+            }
+        }
+        d.syncTags(board.Id)
+        return nil
+    })
+}
+
+func (b boardStore) GetById(ctx context.Context, id int64) (*models.Board, error) {
+    var board models.Board
+    err := b.s.view(ctx, func(d *data) error {
+        i := d.board(id)
+        if i < 0 {
+            return models.ErrNotFound
+        }
+        board = d.Boards[i]
+        board.Columns = columnsByBoard(d, id)
+        board.Tasks = tasksWhere(d, func(t *models.Task) bool { return t.BoardId == id && t.DeletedAt == nil })
+        sort.SliceStable(board.Tasks, func(i, j int) bool {
+            if board.Tasks[i].StatusColumnId != board.Tasks[j].StatusColumnId {
+                return board.Tasks[i].StatusColumnId < board.Tasks[j].StatusColumnId
+            }
+            return board.Tasks[i].Position < board.Tasks[j].Position
+        })
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &board, nil
+}
+
+func (b boardStore) GetAll(ctx context.Context) ([]models.Board, error) {
+    var boards []models.Board
+    err := b.s.view(ctx, func(d *data) error {
+        boards = append(boards, d.Boards...)
+        return nil
+    })
+    // Newest first, like the SQLite backend
+    sort.SliceStable(boards, func(i, j int) bool {
+        if !boards[i].CreatedAt.Equal(boards[j].CreatedAt) {
+            return boards[i].CreatedAt.After(boards[j].CreatedAt)
+        }
+        return boards[i].Id > boards[j].Id
+    })
+    return boards, err
+}
+
+func (b boardStore) Update(ctx context.Context, board *models.Board) error {
+    return b.s.update(ctx, func(d *data) error {
+        if i := d.board(board.Id); i >= 0 {
+            board.UpdatedAt = time.Now()
+            d.Boards[i].Title = board.Title
+            d.Boards[i].Description = board.Description
+            d.Boards[i].UpdatedAt = board.UpdatedAt
+        }
+        return nil
+    })
+}
+
+func (b boardStore) Delete(ctx context.Context, id int64) error {
+    return b.s.update(ctx, func(d *data) error {
+        if i := d.board(id); i >= 0 {
+            d.Boards = append(d.Boards[:i], d.Boards[i+1:]...)
+        }
+        // Cascade to the board's columns and tasks
+        d.Columns = filter(d.Columns, func(c *models.StatusColumn) bool { return c.BoardId != id })
+        d.Tasks = filter(d.Tasks, func(t *models.Task) bool { return t.BoardId != id })
+        d.Tags = filter(d.Tags, func(t *models.Tag) bool { return t.BoardId != id })
+        d.dropOrphanEvents()
+        return nil
+    })
+}
+
+// StatusColumn operations
+type columnStore struct{ s *Store }
+
+func (c columnStore) Create(ctx context.Context, column *models.StatusColumn) error {
+    return c.s.update(ctx, func(d *data) error {
+        return createColumn(d, column)
+    })
+}
+
+func createColumn(d *data, column *models.StatusColumn) error {
+    if d.board(column.BoardId) < 0 {
+        return fmt.Errorf("board %d: %w", column.BoardId, models.ErrNotFound)
+    }
+    if column.Id != 0 && d.column(column.Id) >= 0 {
+        return fmt.Errorf("column %d already exists", column.Id)
+    }
+
+    count := 0
+    for i := range d.Columns {
+        if d.Columns[i].BoardId == column.BoardId {
+            count++
+        }
+    }
+    column.Position = max(0, min(column.Position, count))
+    for i := range d.Columns {
+        if d.Columns[i].BoardId == column.BoardId && d.Columns[i].Position >= column.Position {
+            d.Columns[i].Position++
+        }
+    }
+
+    if column.Id == 0 {
+        d.NextColumnId++
+        column.Id = d.NextColumnId
+    }
+    d.NextColumnId = max(d.NextColumnId, column.Id)
+    d.Columns = append(d.Columns, *column)
+    return nil
+}
+
+func (c columnStore) GetByBoardId(ctx context.Context, boardId int64) ([]models.StatusColumn, error) {
+    var columns []models.StatusColumn
+    err := c.s.view(ctx, func(d *data) error {
+        columns = columnsByBoard(d, boardId)
+        return nil
+    })
+    return columns, err
+}
+
+func columnsByBoard(d *data, boardId int64) []models.StatusColumn {
+    columns := filter(append([]models.StatusColumn(nil), d.Columns...), func(c *models.StatusColumn) bool {
+        return c.BoardId == boardId
+    })
+    sort.SliceStable(columns, func(i, j int) bool { return columns[i].Position < columns[j].Position })
+    return columns
+}
+
+func (c columnStore) Update(ctx context.Context, column *models.StatusColumn) error {
+    return c.s.update(ctx, func(d *data) error {
+        if i := d.column(column.Id); i >= 0 {
+            d.Columns[i].Name = column.Name
+            d.Columns[i].Color = column.Color
+            d.Columns[i].Sort = column.Sort
+        }
+        return nil
+    })
+}
+
+func (c columnStore) Move(ctx context.Context, id int64, position int) error {
+    return c.s.update(ctx, func(d *data) error {
+        i := d.column(id)
+        if i < 0 {
+            return models.ErrNotFound
+        }
+        moved := d.Columns[i]
+        columns := columnsByBoard(d, moved.BoardId)
+        to := max(0, min(position, len(columns)-1))
+        for j := range d.Columns {
+            column := &d.Columns[j]
+            if column.BoardId != moved.BoardId || column.Id == id {
+                continue
+            }
+            if to > moved.Position && column.Position > moved.Position && column.Position <= to {
+                column.Position--
+            }
+            if to < moved.Position && column.Position >= to && column.Position < moved.Position {
+                column.Position++
+            }
+        }
+        d.Columns[i].Position = to
+        return nil
+    })
+}
+
+func (c columnStore) Delete(ctx context.Context, id int64) error {
+    return c.s.update(ctx, func(d *data) error {
+        return deleteColumn(d, id)
+    })
+}
+
+func (c columnStore) DeleteMovingTasks(ctx context.Context, id, targetId int64) error {
+    return c.s.update(ctx, func(d *data) error {
+        i, target := d.column(id), d.column(targetId)
+        if i < 0 || target < 0 {
+            return models.ErrNotFound
+        }
+        if id == targetId || d.Columns[i].BoardId != d.Columns[target].BoardId {
+            return fmt.Errorf("cannot move tasks from column %d to column %d", id, targetId)
+        }
+
+        var last string
+        var moving []int
+        for j := range d.Tasks {
+            switch d.Tasks[j].StatusColumnId {
+            case targetId:
+                last = max(last, d.Tasks[j].Rank)
+            case id:
+                moving = append(moving, j)
+            }
+        }
+        sort.Slice(moving, func(a, b int) bool { return rankLess(&d.Tasks[moving[a]], &d.Tasks[moving[b]]) })
+
+        now := time.Now()
+        for k, rank := range models.RanksBetween(last, "", len(moving)) {
+            task := &d.Tasks[moving[k]]
+            moved := *task
+            moved.StatusColumnId = targetId
+            d.addEvents(models.TaskChanges(*task, moved, d.columnName)...)
+            task.StatusColumnId = targetId
+            task.Rank = rank
+            task.UpdatedAt = now
+            task.Version++
+        }
+        return deleteColumn(d, id)
+    })
+}
+
+func deleteColumn(d *data, id int64) error {
+    i := d.column(id)
+    if i < 0 {
+        return models.ErrNotFound
+    }
+    deleted := d.Columns[i]
+    d.Columns = append(d.Columns[:i], d.Columns[i+1:]...)
+    d.Tasks = filter(d.Tasks, func(t *models.Task) bool { return t.StatusColumnId != id })
+    d.dropOrphanEvents()
+    d.syncTags(deleted.BoardId)
+
+    // Close the gap in the board's column positions
+    for i := range d.Columns {
+        if d.Columns[i].BoardId == deleted.BoardId && d.Columns[i].Position > deleted.Position {
+            d.Columns[i].Position--
+        }
+    }
+    return nil
+}
+
+// Task operations
+type taskStore struct{ s *Store }
+
+func (t taskStore) Create(ctx context.Context, task *models.Task) error {
+    return t.s.update(ctx, func(d *data) error {
+        if d.board(task.BoardId) < 0 {
+            return fmt.Errorf("board %d: %w", task.BoardId, models.ErrNotFound)
+        }
+        if d.column(task.StatusColumnId) < 0 {
+            return fmt.Errorf("column %d: %w", task.StatusColumnId, models.ErrNotFound)
+        }
+        now := time.Now()
+        task.CreatedAt = now
+        task.UpdatedAt = now
+        task.Rank = d.rankAt(task.StatusColumnId, 0, task.Position)
+        task.Tags = models.FormatTags(models.ParseTags(task.Tags))
+
+        d.NextTaskId++
+        task.Id = d.NextTaskId
+        task.Version = 1
+        d.Tasks = append(d.Tasks, *task)
+        d.addEvents(models.TaskEvent{
+            TaskId:   task.Id,
+            BoardId:  task.BoardId,
+            Kind:     models.EventCreated,
+            NewValue: d.columnName(task.StatusColumnId),
+        })
+        d.syncTags(task.BoardId)
+        return nil
+    })
+}
+
+func (t taskStore) GetByColumnId(ctx context.Context, columnId int64) ([]models.Task, error) {
+    var tasks []models.Task
+    err := t.s.view(ctx, func(d *data) error {
+        tasks = tasksWhere(d, func(t *models.Task) bool { return t.StatusColumnId == columnId && t.DeletedAt == nil })
+        return nil
+    })
+    sort.Slice(tasks, func(i, j int) bool { return rankLess(&tasks[i], &tasks[j]) })
+    return tasks, err
+}
+
+func (t taskStore) GetByBoardId(ctx context.Context, boardId int64) ([]models.Task, error) {
+    var tasks []models.Task
+    err := t.s.view(ctx, func(d *data) error {
+        tasks = tasksWhere(d, func(t *models.Task) bool { return t.BoardId == boardId && t.DeletedAt == nil })
+        return nil
+    })
+    sort.SliceStable(tasks, func(i, j int) bool {
+        if tasks[i].StatusColumnId != tasks[j].StatusColumnId {
+            return tasks[i].StatusColumnId < tasks[j].StatusColumnId
+        }
+        return rankLess(&tasks[i], &tasks[j])
+    })
+    return tasks, err
+}
+
+func (t taskStore) GetDeletedByBoardId(ctx context.Context, boardId int64) ([]models.Task, error) {
+    var tasks []models.Task
+    err := t.s.view(ctx, func(d *data) error {
+        tasks = tasksWhere(d, func(t *models.Task) bool { return t.BoardId == boardId && t.DeletedAt != nil })
+        return nil
+    })
+    // Most recently deleted first
+    sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].DeletedAt.After(*tasks[j].DeletedAt) })
+    return tasks, err
+}
+
+func (t taskStore) Update(ctx context.Context, task *models.Task) error {
+    now := time.Now()
+    err := t.s.update(ctx, func(d *data) error {
+        i := d.task(task.Id)
+        if i < 0 {
+            return models.ErrNotFound
+        }
+        if d.column(task.StatusColumnId) < 0 {
+            return fmt.Errorf("column %d: %w", task.StatusColumnId, models.ErrNotFound)
+        }
+        if d.Tasks[i].Version != task.Version {
+            return &models.ConflictError{Current: d.Tasks[i]}
+        }
+
+        // Board, order, creation time and trash state are not changed by
+        // updates
+        updated := *task
+        updated.BoardId = d.Tasks[i].BoardId
+        updated.Rank = d.Tasks[i].Rank
+        updated.CreatedAt = d.Tasks[i].CreatedAt
+        updated.DeletedAt = d.Tasks[i].DeletedAt
+        updated.Tags = models.FormatTags(models.ParseTags(task.Tags))
+        updated.UpdatedAt = now
+        updated.Version++
+        d.addEvents(models.TaskChanges(d.Tasks[i], updated, d.columnName)...)
+        d.Tasks[i] = updated
+        d.syncTags(updated.BoardId)
+        return nil
+    })
+    if err == nil {
+        task.Tags = models.FormatTags(models.ParseTags(task.Tags))
+        task.UpdatedAt = now
+        task.Version++
+    }
+    return err
+}
+
+func (t taskStore) Delete(ctx context.Context, id int64) error {
+    return t.s.update(ctx, func(d *data) error {
+        if i := d.task(id); i >= 0 && d.Tasks[i].DeletedAt == nil {
+            now := time.Now()
+            d.Tasks[i].DeletedAt = &now
+            d.Tasks[i].UpdatedAt = now
+            d.Tasks[i].Version++
+            d.addEvents(models.TaskEvent{TaskId: id, BoardId: d.Tasks[i].BoardId, Kind: models.EventDeleted})
+        }
+        return nil
+    })
+}
+
+func (t taskStore) Restore(ctx context.Context, id int64) error {
+    return t.s.update(ctx, func(d *data) error {
+        if i := d.task(id); i >= 0 && d.Tasks[i].DeletedAt != nil {
+            d.Tasks[i].DeletedAt = nil
+            d.Tasks[i].UpdatedAt = time.Now()
+            d.Tasks[i].Version++
+            d.addEvents(models.TaskEvent{TaskId: id, BoardId: d.Tasks[i].BoardId, Kind: models.EventRestored})
+        }
+        return nil
+    })
+}
+
+func (t taskStore) Purge(ctx context.Context, id int64) error {
+    return t.s.update(ctx, func(d *data) error {
+        if i := d.task(id); i >= 0 && d.Tasks[i].DeletedAt != nil {
+            boardId := d.Tasks[i].BoardId
+            d.Tasks = append(d.Tasks[:i], d.Tasks[i+1:]...)
+            d.dropOrphanEvents()
+            d.syncTags(boardId)
+        }
+        return nil
+    })
+}
+
+func (t taskStore) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+    var purged int64
+    err := t.s.update(ctx, func(d *data) error {
+        boards := make(map[int64]bool)
+        d.Tasks = filter(d.Tasks, func(t *models.Task) bool {
+            if t.DeletedAt != nil && t.DeletedAt.Before(cutoff) {
+                boards[t.BoardId] = true
+                purged++
+                return false
+            }
+            return true
+        })
+        d.dropOrphanEvents()
+        for boardId := range boards {
+            d.syncTags(boardId)
+        }
+        return nil
+    })
+    return purged, err
+}
+
+func (t taskStore) MoveToColumn(ctx context.Context, taskId, columnId int64, position int) error {
+    return t.s.update(ctx, func(d *data) error {
+        if d.column(columnId) < 0 {
+            return fmt.Errorf("column %d: %w", columnId, models.ErrNotFound)
+        }
+        i := d.task(taskId)
+        if i < 0 {
+            return models.ErrNotFound
+        }
+        moved := d.Tasks[i]
+        moved.StatusColumnId = columnId
+        d.addEvents(models.TaskChanges(d.Tasks[i], moved, d.columnName)...)
+        d.Tasks[i].StatusColumnId = columnId
+        d.Tasks[i].Rank = d.rankAt(columnId, taskId, position)
+        d.Tasks[i].UpdatedAt = time.Now()
+        d.Tasks[i].Version++
+        return nil
+    })
+}
+
+func (t taskStore) MoveToBoard(ctx context.Context, taskId, columnId int64, position int) error {
+    return t.s.update(ctx, func(d *data) error {
+        c := d.column(columnId)
+        if c < 0 {
+            return fmt.Errorf("column %d: %w", columnId, models.ErrNotFound)
+        }
+        i := d.task(taskId)
+        if i < 0 {
+            return models.ErrNotFound
+        }
+        before, moved := d.Tasks[i], d.Tasks[i]
+        moved.BoardId = d.Columns[c].BoardId
+        moved.StatusColumnId = columnId
+        d.addEvents(models.BoardChanges(before, moved, d.boardTitle, d.columnName)...)
+        d.Tasks[i].BoardId = moved.BoardId
+        d.Tasks[i].StatusColumnId = columnId
+        d.Tasks[i].Rank = d.rankAt(columnId, taskId, position)
+        d.Tasks[i].UpdatedAt = time.Now()
+        d.Tasks[i].Version++
+        // Tags belong to a board, so the task takes on the target board's
+        // tags of the same names
+        d.syncTags(moved.BoardId)
+        d.syncTags(before.BoardId)
+        return nil
+    })
+}
+
+func (t taskStore) Search(ctx context.Context, query string, limit int) ([]models.SearchHit, error) {
+    type scored struct {
+        hit   models.SearchHit
+        score int
+    }
+    var matches []scored
+    err := t.s.view(ctx, func(d *data) error {
+        for _, task := range d.Tasks {
+            if task.DeletedAt != nil {
+                continue
+            }
+            score := models.MatchScore(task, query)
+            if score == 0 {
+                continue
+            }
+            task.Position = d.position(&task)
+            hit := models.SearchHit{Task: task}
+            if i := d.board(task.BoardId); i >= 0 {
+                hit.BoardTitle = d.Boards[i].Title
+            }
+            if i := d.column(task.StatusColumnId); i >= 0 {
+                hit.ColumnName = d.Columns[i].Name
+            }
+            matches = append(matches, scored{hit, score})
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].score != matches[j].score {
+            return matches[i].score > matches[j].score
+        }
+        return matches[i].hit.Task.UpdatedAt.After(matches[j].hit.Task.UpdatedAt)
+    })
+    if len(matches) > limit {
+        matches = matches[:limit]
+    }
+    hits := make([]models.SearchHit, len(matches))
+    for i := range matches {
+        hits[i] = matches[i].hit
+    }
+    return hits, nil
+}
+
+func (t taskStore) GetAssigned(ctx context.Context, assignee string) ([]models.SearchHit, error) {
+    type placed struct {
+        hit            models.SearchHit
+        columnPosition int
+    }
+    var assigned []placed
+    err := t.s.view(ctx, func(d *data) error {
+        for _, task := range d.Tasks {
+            if task.DeletedAt != nil || !strings.EqualFold(task.Assignee, strings.TrimSpace(assignee)) {
+                continue
+            }
+            task.Position = d.position(&task)
+            p := placed{hit: models.SearchHit{Task: task}}
+            if i := d.board(task.BoardId); i >= 0 {
+                p.hit.BoardTitle = d.Boards[i].Title
+            }
+            if i := d.column(task.StatusColumnId); i >= 0 {
+                p.hit.ColumnName = d.Columns[i].Name
+                p.columnPosition = d.Columns[i].Position
+            }
+            assigned = append(assigned, p)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    sort.SliceStable(assigned, func(i, j int) bool {
+        a, b := assigned[i], assigned[j]
+        if a.hit.Task.BoardId != b.hit.Task.BoardId {
+            return a.hit.Task.BoardId < b.hit.Task.BoardId
+        }
+        if a.columnPosition != b.columnPosition {
+            return a.columnPosition < b.columnPosition
+        }
+        return a.hit.Task.Position < b.hit.Task.Position
+    })
+    hits := make([]models.SearchHit, len(assigned))
+    for i := range assigned {
+        hits[i] = assigned[i].hit
+    }
+    return hits, nil
+}
+
+func (t taskStore) GetById(ctx context.Context, id int64) (*models.Task, error) {
+    var task *models.Task
+    err := t.s.view(ctx, func(d *data) error {
+        i := d.task(id)
+        if i < 0 {
+            return fmt.Errorf("task %d: %w", id, models.ErrNotFound)
+        }
+        found := d.Tasks[i]
+        found.Position = d.position(&found)
+        task = &found
+        return nil
+    })
+    return task, err
+}
+
+func (t taskStore) GetEvents(ctx context.Context, taskId int64) ([]models.TaskEvent, error) {
+    var events []models.TaskEvent
+    err := t.s.view(ctx, func(d *data) error {
+        for _, event := range d.Events {
+            if event.TaskId == taskId {
+                events = append(events, event)
+            }
+        }
+        return nil
+    })
+    return events, err
+}
+
+// tasksWhere returns copies of the tasks keep accepts, with their positions
+// filled in.
+func tasksWhere(d *data, keep func(t *models.Task) bool) []models.Task {
+    tasks := filter(append([]models.Task(nil), d.Tasks...), keep)
+    for i := range tasks {
+        tasks[i].Position = d.position(&tasks[i])
+    }
+    return tasks
+}
+
+// filter keeps the elements of s for which keep returns true, in place.
+func filter[T any](s []T, keep func(*T) bool) []T {
+    out := s[:0]
+    for i := range s {
+        if keep(&s[i]) {
+            out = append(out, s[i])
+        }
+    }
+    return out
+}
+
+// Tag operations
+type tagStore struct{ s *Store }
+
+func (t tagStore) GetByBoardId(ctx context.Context, boardId int64) ([]models.Tag, error) {
+    var tags []models.Tag
+    err := t.s.view(ctx, func(d *data) error {
+        tags = filter(append([]models.Tag(nil), d.Tags...), func(tag *models.Tag) bool { return tag.BoardId == boardId })
+        return nil
+    })
+    // By name in any case, like the SQLite backend
+    sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name) })
+    return tags, err
+}
+
+func (t tagStore) SetColor(ctx context.Context, id int64, color string) error {
+    return t.s.update(ctx, func(d *data) error {
+        for i := range d.Tags {
+            if d.Tags[i].Id == id {
+                d.Tags[i].Color = color
+                return nil
+            }
+        }
+        return models.ErrNotFound
+    })
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrConflict is returned, wrapped in a *ConflictError, when a task was
+// changed by someone else after it was read.
+var ErrConflict = errors.New("task was changed by someone else")
+
+// ConflictError carries the stored task that a rejected update would have
+// overwritten, so callers can reload or merge.
+type ConflictError struct {
+    Current Task
+}
+
+func (e *ConflictError) Error() string {
+    return fmt.Sprintf("task %d: %v", e.Current.Id, ErrConflict)
+}
+
+func (e *ConflictError) Unwrap() error {
+    return ErrConflict
+}
+
+// ChangedFields lists the fields that differ between two versions of a
+// task, using the field names of TaskEvent.
+func ChangedFields(before, after Task) []string {
+    var fields []string
+    for _, event := range TaskChanges(before, after, func(int64) string { return "" }) {
+        fields = append(fields, event.Field)
+    }
+    return fields
+}
+
+// MergeTask combines two concurrent edits of base. Fields that mine changed
+// take mine's value and every other field keeps theirs, so where both sides
+// changed the same field mine wins. Placement and version come from theirs.
+func MergeTask(base, mine, theirs Task) Task {
+    merged := theirs
+    if mine.title != base.title {
+        merged.title = mine.title
+    }
+    if mine.description != base.description {
+        merged.description = mine.description
+    }
+    if mine.Priority != base.Priority {
+        merged.Priority = mine.Priority
+    }
+    if formatDue(mine.DueDate) != formatDue(base.DueDate) {
+        merged.DueDate = mine.DueDate
+    }
+    if mine.Assignee != base.Assignee {
+        merged.Assignee = mine.Assignee
+    }
+    if mine.Tags != base.Tags {
+        merged.Tags = mine.Tags
+    }
+    return merged
+}
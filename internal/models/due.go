@@ -0,0 +1,150 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A due date is a calendar day. It is kept as midnight UTC so that it names
+// the same day wherever it is read, and only "today" depends on the time
+// zone of the user.
+
+// DueOn returns the due date for the day t falls on in its location.
+func DueOn(t time.Time) time.Time {
+    y, m, d := t.Date()
+    return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DaysUntil returns how many days away due is from the day of now, in
+// now's location: 0 when it is due today, negative when it is overdue.
+func DaysUntil(due, now time.Time) int {
+    // Both are UTC midnights, which are always 24 hours apart
+    return int(DueOn(due.UTC()).Sub(DueOn(now)).Hours() / 24)
+}
+
+// ParseDue reads a due date as typed into the task form, relative to now
+// in now's location. It accepts
+//
+//   - "today", "tomorrow" and "yesterday"
+//   - a weekday, as "fri" or "friday", for the next one after today
+//   - "next week", "next month" and "next year"
+//   - "in 3 days", "in a week", "in 2 months", or short as "3d", "2w"
+//   - a date, as "2026-11-03", "nov 3" or "3 nov"; without a year, the
+//     next one to come
+//
+// Months are added by the calendar, so a month after January 31 is the last
+// day of February. An empty input means no due date and returns nil.
+func ParseDue(input string, now time.Time) (*time.Time, error) {
+    input = strings.Join(strings.Fields(strings.ToLower(input)), " ")
+    if input == "" {
+        return nil, nil
+    }
+    today := DueOn(now)
+
+    due, ok := parseRelativeDue(input, today)
+    if !ok {
+        due, ok = parseCalendarDue(input, today)
+    }
+    if !ok {
+        return nil, fmt.Errorf("%q is not a due date; try tomorrow, fri, in 3 days or 2026-11-03", input)
+    }
+    return &due, nil
+}
+
+func parseRelativeDue(input string, today time.Time) (time.Time, bool) {
+    switch input {
+    case "today", "tod":
+        return today, true
+    case "tomorrow", "tom", "tmr":
+        return today.AddDate(0, 0, 1), true
+    case "yesterday":
+        return today.AddDate(0, 0, -1), true
+    case "next week":
+        return today.AddDate(0, 0, 7), true
+    case "next month":
+        return addMonths(today, 1), true
+    case "next year":
+        return addMonths(today, 12), true
+    }
+    if weekday, ok := parseWeekday(input); ok {
+        days := (int(weekday) - int(today.Weekday()) + 7) % 7
+        if days == 0 {
+            days = 7
+        }
+        return today.AddDate(0, 0, days), true
+    }
+
+    // "in 3 days", "in a week", "3d"
+    span := strings.TrimPrefix(input, "in ")
+    amount, unit, ok := strings.Cut(span, " ")
+    if !ok {
+        i := strings.IndexFunc(span, func(r rune) bool { return r < '0' || r > '9' })
+        if i <= 0 {
+            return time.Time{}, false
+        }
+        amount, unit = span[:i], span[i:]
+    }
+    n, err := strconv.Atoi(amount)
+    if amount == "a" || amount == "an" {
+        n, err = 1, nil
+    }
+    if err != nil || n < 0 {
+        return time.Time{}, false
+    }
+    switch strings.TrimSuffix(unit, "s") {
+    case "d", "day":
+        return today.AddDate(0, 0, n), true
+    case "w", "week":
+        return today.AddDate(0, 0, 7*n), true
+    case "m", "month":
+        return addMonths(today, n), true
+    case "y", "year":
+        return addMonths(today, 12*n), true
+    }
+    return time.Time{}, false
+}
+
+func parseCalendarDue(input string, today time.Time) (time.Time, bool) {
+    if due, err := time.Parse("2006-01-02", input); err == nil {
+        return due, true
+    }
+    for _, layout := range []string{"Jan 2", "2 Jan", "January 2", "2 January"} {
+        date, err := time.Parse(layout, input)
+        if err != nil {
+            continue
+        }
+        // The first year from this one where the day is still to come and
+        // exists, as February 29 does not every year
+        for year := today.Year(); year < today.Year()+9; year++ {
+            due := time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+            if due.Month() == date.Month() && !due.Before(today) {
+                return due, true
+            }
+        }
+    }
+    return time.Time{}, false
+}
+
+// parseWeekday reads a weekday name, or its first three letters or more.
+func parseWeekday(input string) (time.Weekday, bool) {
+    if len(input) < 3 {
+        return 0, false
+    }
+    for day := time.Sunday; day <= time.Saturday; day++ {
+        if strings.HasPrefix(strings.ToLower(day.String()), input) {
+            return day, true
+        }
+    }
+    return 0, false
+}
+
+// addMonths adds n months to a due date, keeping to the last day of the
+// month when the day does not exist there.
+func addMonths(due time.Time, n int) time.Time {
+    y, m, d := due.Date()
+    first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+    last := first.AddDate(0, 1, -1).Day()
+    return first.AddDate(0, 0, min(d, last)-1)
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDue(t *testing.T) {
+    newYork, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        t.Skipf("no time zone data: %v", err)
+    }
+    day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+    tests := []struct {
+        name  string
+        now   time.Time
+        input string
+        want  time.Time
+    }{
+        {"month end", time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC), "in 1 month", day(2026, 2, 28)},
+        {"month end in leap year", time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC), "next month", day(2024, 2, 29)},
+        {"month end to shorter month", time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC), "1m", day(2026, 4, 30)},
+        {"leap day to next year", time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC), "next year", day(2025, 2, 28)},
+        {"leap day in leap year", time.Date(2028, 1, 10, 10, 0, 0, 0, time.UTC), "feb 29", day(2028, 2, 29)},
+        {"leap day in non-leap year", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "feb 29", day(2028, 2, 29)},
+        {"iso leap day", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "2028-02-29", day(2028, 2, 29)},
+        {"weekday later this week", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "sat", day(2026, 10, 17)},
+        {"weekday wraps to next week", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "mon", day(2026, 10, 19)},
+        {"same weekday is next week", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "Friday", day(2026, 10, 23)},
+        {"weekday wraps into next year", time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC), "tue", day(2027, 1, 5)},
+        {"days across spring DST", time.Date(2026, 3, 7, 23, 30, 0, 0, newYork), "in 3 days", day(2026, 3, 10)},
+        {"days across fall DST", time.Date(2026, 10, 31, 23, 30, 0, 0, newYork), "in 2 days", day(2026, 11, 2)},
+        {"tomorrow on DST day", time.Date(2026, 3, 8, 0, 30, 0, 0, newYork), "tomorrow", day(2026, 3, 9)},
+        {"local day, not UTC day", time.Date(2026, 12, 31, 23, 30, 0, 0, newYork), "tomorrow", day(2027, 1, 1)},
+        {"short days", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "3D", day(2026, 10, 19)},
+        {"a week", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "in a week", day(2026, 10, 23)},
+        {"month day to come", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "nov 3", day(2026, 11, 3)},
+        {"day month to come", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "3 November", day(2026, 11, 3)},
+        {"month day today", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "oct 16", day(2026, 10, 16)},
+        {"month day past rolls over", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), "oct 1", day(2027, 10, 1)},
+        {"month day past rolls over at year end", time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC), "jan 1", day(2027, 1, 1)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := ParseDue(tt.input, tt.now)
+            if err != nil {
+                t.Fatalf("ParseDue(%q): %v", tt.input, err)
+            }
+            if got == nil || !got.Equal(tt.want) {
+                t.Errorf("ParseDue(%q) = %v, want %v", tt.input, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseDueEmptyAndInvalid(t *testing.T) {
+    now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+    if got, err := ParseDue("  ", now); got != nil || err != nil {
+        t.Errorf(`ParseDue("  ") = %v, %v, want no due date`, got, err)
+    }
+    for _, input := range []string{"someday", "2026-02-30", "next fri", "in -1 days", "fe"} {
+        if got, err := ParseDue(input, now); err == nil {
+            t.Errorf("ParseDue(%q) = %v, want an error", input, got)
+        }
+    }
+}
+
+func TestDaysUntil(t *testing.T) {
+    newYork, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        t.Skipf("no time zone data: %v", err)
+    }
+    due := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name string
+        now  time.Time
+        want int
+    }{
+        {"just before local midnight", time.Date(2026, 3, 7, 23, 59, 0, 0, newYork), 1},
+        {"just after local midnight", time.Date(2026, 3, 8, 0, 1, 0, 0, newYork), 0},
+        {"late on the day, next day in UTC", time.Date(2026, 3, 8, 23, 30, 0, 0, newYork), 0},
+        {"after the day", time.Date(2026, 3, 9, 0, 1, 0, 0, newYork), -1},
+        {"UTC midnight", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), 0},
+        {"weeks ahead across DST", time.Date(2026, 2, 22, 12, 0, 0, 0, newYork), 14},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := DaysUntil(due, tt.now); got != tt.want {
+                t.Errorf("DaysUntil(%v, %v) = %d, want %d", due, tt.now, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// formatDue renders a due date for the history, empty when unset.
+func formatDue(due *time.Time) string {
+    if due == nil {
+        return ""
+    }
+    return due.Format("2006-01-02")
+}
+
+// TaskChanges lists the events describing how before became after. Column
+// ids are resolved to names with columnName so the history stays readable
+// after columns are renamed or deleted.
+func TaskChanges(before, after Task, columnName func(id int64) string) []TaskEvent {
+    var events []TaskEvent
+    add := func(kind, field, oldValue, newValue string) {
+        events = append(events, TaskEvent{
+            TaskId:   after.Id,
+            BoardId:  after.BoardId,
+            Kind:     kind,
+            Field:    field,
+            OldValue: oldValue,
+            NewValue: newValue,
+        })
+    }
+
+    if before.StatusColumnId != after.StatusColumnId {
+        add(EventMoved, "column", columnName(before.StatusColumnId), columnName(after.StatusColumnId))
+    }
+    if before.Priority != after.Priority {
+        add(EventPriority, "priority", strconv.Itoa(before.Priority), strconv.Itoa(after.Priority))
+    }
+    if before.title != after.title {
+        add(EventEdited, "title", before.title, after.title)
+    }
+    if before.description != after.description {
+        add(EventEdited, "description", before.description, after.description)
+    }
+    if formatDue(before.DueDate) != formatDue(after.DueDate) {
+        add(EventEdited, "due_date", formatDue(before.DueDate), formatDue(after.DueDate))
+    }
+    if before.Assignee != after.Assignee {
+        add(EventEdited, "assignee", before.Assignee, after.Assignee)
+    }
+    if before.Tags != after.Tags {
+        add(EventEdited, "tags", before.Tags, after.Tags)
+    }
+    return events
+}
+
+// BoardChanges lists the events describing a move of a task from before to
+// after, which may be on another board. A change of board is recorded as
+// one event naming both the board and the column.
+func BoardChanges(before, after Task, boardTitle, columnName func(id int64) string) []TaskEvent {
+    events := TaskChanges(before, after, columnName)
+    if before.BoardId == after.BoardId {
+        return events
+    }
+    for i := range events {
+        if events[i].Field == "column" {
+            events[i].Field = "board"
+            events[i].OldValue = boardTitle(before.BoardId) + " › " + events[i].OldValue
+            events[i].NewValue = boardTitle(after.BoardId) + " › " + events[i].NewValue
+        }
+    }
+    return events
+}
+
+// insertEvents records events, stamping each with the current time.
+func insertEvents(ctx context.Context, db DBInterface, events ...TaskEvent) error {
+    query := `
+        INSERT INTO task_events (task_id, board_id, kind, field, old_value, new_value, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+    now := time.Now()
+    for _, event := range events {
+        _, err := db.ExecContext(ctx, query,
+            event.TaskId, event.BoardId, event.Kind, event.Field,
+            event.OldValue, event.NewValue, now,
+        )
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// columnNames returns a lookup of column names for TaskChanges. Unknown
+// columns resolve to an empty name.
+func columnNames(ctx context.Context, db DBInterface) func(id int64) string {
+    return func(id int64) string {
+        var name string
+        db.QueryRowContext(ctx, `SELECT name FROM status_columns WHERE id = ?`, id).Scan(&name)
+        return name
+    }
+}
+
+// boardTitles returns a lookup of board titles for BoardChanges.
+func boardTitles(ctx context.Context, db DBInterface) func(id int64) string {
+    return func(id int64) string {
+        var title string
+        db.QueryRowContext(ctx, `SELECT title FROM boards WHERE id = ?`, id).Scan(&title)
+        return title
+    }
+}
+
+// GetEvents returns a task's history, oldest first.
+func (r *TaskRepository) GetEvents(ctx context.Context, taskId int64) ([]TaskEvent, error) {
+    query := `
+        SELECT id, task_id, board_id, kind, field, old_value, new_value, created_at
+        FROM task_events
+        WHERE task_id = ?
+        ORDER BY created_at, id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, taskId)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var events []TaskEvent
+    for rows.Next() {
+        event := TaskEvent{}
+        err := rows.Scan(
+            &event.Id, &event.TaskId, &event.BoardId, &event.Kind, &event.Field,
+            &event.OldValue, &event.NewValue, &event.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        events = append(events, event)
+    }
+
+    return events, rows.Err()
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,8 +12,15 @@ type StatusColumn struct {
     Name     string `json:"name" db:"name"`
     Position int    `json:"position" db:"position"` // Order of columns in the board
     Color    string `json:"color" db:"color"`       // Optional color for the column
+    Sort     string `json:"sort" db:"sort"`         // How tasks are ordered: SortManual or SortPriority
 }
 
+// Orders of the tasks in a column
+const (
+    SortManual   = ""         // By rank, as the user placed them
+    SortPriority = "priority" // Most pressing first, then by rank
+)
+
 // Board represents a kanban board with dynamic status columns
 type Board struct {
     Id          int64          `json:"id" db:"id"`
@@ -29,17 +37,66 @@ type Task struct {
     Id             int64     `json:"id" db:"id"`
     BoardId        int64     `json:"board_id" db:"board_id"`
     StatusColumnId int64     `json:"status_column_id" db:"status_column_id"`
-    title          string    `json:"title" db:"title"`
-    description    string    `json:"description" db:"description"`
-    Position       int       `json:"position" db:"position"` // Order within the column
+    title          string    // exposed via Title() for list.Item
+    description    string    // exposed via Description() for list.Item
+    Position       int       `json:"position" db:"position"` // Index within the column, derived from Rank when read
+    Rank           string    `json:"rank" db:"rank"`         // Sort key within the column, see RankBetween
     CreatedAt      time.Time `json:"created_at" db:"created_at"`
     UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 
     // Optional fields for future expansion
-    Priority    int       `json:"priority" db:"priority"`         // 1=low, 2=medium, 3=high
+    Priority    int       `json:"priority" db:"priority"`         // PriorityLow to PriorityUrgent
     DueDate     *time.Time `json:"due_date" db:"due_date"`
     Assignee    string    `json:"assignee" db:"assignee"`
-    Tags        string    `json:"tags" db:"tags"` // JSON array or comma-separated
+    Tags        string    `json:"tags" db:"tags"` // Comma-separated tag names, see ParseTags
+
+    // Set while the task is in the trash
+    DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+    // Incremented by every write; Update fails with ErrConflict when the
+    // stored version differs from the one the caller read
+    Version int64 `json:"version" db:"version"`
+}
+
+// Tag is a label the tasks of a board can carry. Tags are created as tasks
+// are given them and belong to one board; two boards may both have a tag of
+// the same name, in different colors.
+type Tag struct {
+    Id      int64  `json:"id" db:"id"`
+    BoardId int64  `json:"board_id" db:"board_id"`
+    Name    string `json:"name" db:"name"`   // Unique within the board, in any case
+    Color   string `json:"color" db:"color"` // #rrggbb, or "" for the default
+}
+
+// Task priorities, from least to most pressing
+const (
+    PriorityLow    = 1
+    PriorityMedium = 2
+    PriorityHigh   = 3
+    PriorityUrgent = 4
+)
+
+// Kinds of TaskEvent
+const (
+    EventCreated  = "created"
+    EventEdited   = "edited"
+    EventMoved    = "moved"
+    EventPriority = "priority"
+    EventDeleted  = "deleted"
+    EventRestored = "restored"
+)
+
+// TaskEvent records a single change to a task. Edits produce one event per
+// changed field, with the values before and after the change.
+type TaskEvent struct {
+    Id        int64     `json:"id" db:"id"`
+    TaskId    int64     `json:"task_id" db:"task_id"`
+    BoardId   int64     `json:"board_id" db:"board_id"`
+    Kind      string    `json:"kind" db:"kind"`
+    Field     string    `json:"field" db:"field"`         // Changed field for edits, e.g. "title"
+    OldValue  string    `json:"old_value" db:"old_value"` // Column name for moves
+    NewValue  string    `json:"new_value" db:"new_value"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 // BubbleTea list.Item interface methods
@@ -63,16 +120,42 @@ func (t *Task) SetDescription(description string) {
     t.description = description
 }
 
+// taskJSON carries the unexported list.Item fields alongside the rest of
+// the task when encoding to and from JSON.
+type taskJSON struct {
+    taskAlias
+    Title       string `json:"title"`
+    Description string `json:"description"`
+}
+
+// taskAlias drops Task's methods so encoding does not recurse.
+type taskAlias Task
+
+func (t Task) MarshalJSON() ([]byte, error) {
+    return json.Marshal(taskJSON{taskAlias(t), t.title, t.description})
+}
+
+func (t *Task) UnmarshalJSON(data []byte) error {
+    var tj taskJSON
+    if err := json.Unmarshal(data, &tj); err != nil {
+        return err
+    }
+    *t = Task(tj.taskAlias)
+    t.title = tj.Title
+    t.description = tj.Description
+    return nil
+}
+
 // NewTask creates a new Task with the given title and description
 func NewTask(title, description string) Task {
     now := time.Now()
     return Task{
         title:       title,
         description: description,
-        Position:    0, // Will be set when added to a column
+        Position:    0, // Where Create places it in its column
         CreatedAt:   now,
         UpdatedAt:   now,
-        Priority:    1, // Default to low priority
+        Priority:    PriorityLow,
     }
 }
 
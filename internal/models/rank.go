@@ -0,0 +1,99 @@
+package models
+
+import "strings"
+
+// rankDigits are the digits of a rank, in sort order. Ranks are compared as
+// plain strings, so a task can always be placed between two others by
+// giving it a rank between theirs, without renumbering the rest of the
+// column.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// RankLimit is the longest rank a store hands out before respreading the
+// column with SpreadRanks. Placing a task ahead of the first one, or
+// between two adjacent ranks, costs a digit each time.
+const RankLimit = 12
+
+// RankBetween returns a rank that sorts after before and ahead of after.
+// An empty before means the start of the column and an empty after its
+// end. The result never ends in the lowest digit, so there is always room
+// ahead of it.
+func RankBetween(before, after string) string {
+    var rank []byte
+    for i := 0; ; i++ {
+        lo := 0
+        if i < len(before) {
+            lo = strings.IndexByte(rankDigits, before[i])
+        }
+        hi := len(rankDigits)
+        if after != "" && i < len(after) {
+            hi = strings.IndexByte(rankDigits, after[i])
+        }
+
+        if hi-lo > 1 {
+            return string(append(rank, rankDigits[(lo+hi)/2]))
+        }
+        rank = append(rank, rankDigits[lo])
+        if lo < hi {
+            // Already ahead of after; only before bounds the rest
+            after = ""
+        }
+    }
+}
+
+// RankAt returns a rank placing a task at index among the tasks ranked by
+// ranks, which must be in order. Equal neighbours, left by two processes
+// ranking at once, share their rank and the tie goes to the task id.
+func RankAt(ranks []string, index int) string {
+    index = max(0, min(index, len(ranks)))
+    var before, after string
+    if index > 0 {
+        before = ranks[index-1]
+    }
+    if index < len(ranks) {
+        after = ranks[index]
+    }
+    if after != "" && before >= after {
+        return before
+    }
+    return RankBetween(before, after)
+}
+
+// RanksBetween returns n ranks in order between before and after, as
+// RankBetween does for one.
+func RanksBetween(before, after string, n int) []string {
+    // Extending a rank sorts after it but, as RankBetween never returns a
+    // prefix of after, still ahead of after
+    prefix := RankBetween(before, after)
+    ranks := SpreadRanks(n)
+    for i := range ranks {
+        ranks[i] = prefix + ranks[i]
+    }
+    return ranks
+}
+
+// SpreadRanks returns n ranks in order, evenly spaced and of equal length,
+// for ranking a whole column at once.
+func SpreadRanks(n int) []string {
+    width, room := 1, len(rankDigits)
+    for room <= n {
+        width++
+        room *= len(rankDigits)
+    }
+
+    ranks := make([]string, n)
+    for i := range ranks {
+        value := (i + 1) * room / (n + 1)
+        digits := make([]byte, width)
+        for j := width - 1; j >= 0; j-- {
+            digits[j] = rankDigits[value%len(rankDigits)]
+            value /= len(rankDigits)
+        }
+        rank := string(digits)
+        if strings.HasSuffix(rank, rankDigits[:1]) {
+            // Keep room ahead of it, as RankBetween does
+            rank += rankDigits[len(rankDigits)/2 : len(rankDigits)/2+1]
+        }
+        ranks[i] = rank
+    }
+    return ranks
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+    tests := []struct {
+        name          string
+        before, after string
+    }{
+        {"empty column", "", ""},
+        {"start of column", "", "i"},
+        {"start ahead of lowest digit", "", "1"},
+        {"start ahead of long rank", "", "05"},
+        {"end of column", "i", ""},
+        {"end after highest digit", "z", ""},
+        {"gap", "a", "k"},
+        {"adjacent digits", "a", "b"},
+        {"adjacent longer ranks", "a5", "a6"},
+        {"prefix of after", "a", "ai"},
+        {"before longer than after", "az", "b"},
+        {"highest digits", "zz", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := RankBetween(tt.before, tt.after)
+            if got <= tt.before {
+                t.Errorf("RankBetween(%q, %q) = %q, not after %q", tt.before, tt.after, got, tt.before)
+            }
+            if tt.after != "" && got >= tt.after {
+                t.Errorf("RankBetween(%q, %q) = %q, not ahead of %q", tt.before, tt.after, got, tt.after)
+            }
+            if strings.HasSuffix(got, rankDigits[:1]) {
+                t.Errorf("RankBetween(%q, %q) = %q, ends in the lowest digit", tt.before, tt.after, got)
+            }
+        })
+    }
+}
+
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+    // Always room ahead of the first rank and between the last two
+    first := RankBetween("", "")
+    lo, hi := first, RankBetween(first, "")
+    for i := 0; i < 50; i++ {
+        top := RankBetween("", first)
+        if top >= first {
+            t.Fatalf("insert %d at the top: %q is not ahead of %q", i, top, first)
+        }
+        first = top
+
+        mid := RankBetween(lo, hi)
+        if mid <= lo || mid >= hi {
+            t.Fatalf("insert %d between %q and %q: got %q", i, lo, hi, mid)
+        }
+        lo = mid
+    }
+}
+
+func TestRankAt(t *testing.T) {
+    tests := []struct {
+        name  string
+        ranks []string
+        index int
+        want  string // Empty to only check the order
+    }{
+        {"empty column", nil, 0, ""},
+        {"start", []string{"i", "r"}, 0, ""},
+        {"middle", []string{"i", "r"}, 1, ""},
+        {"end", []string{"i", "r"}, 2, ""},
+        {"past the end", []string{"i", "r"}, 5, ""},
+        {"before the start", []string{"i", "r"}, -1, ""},
+        {"tied neighbours share the rank", []string{"i", "i"}, 1, "i"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := RankAt(tt.ranks, tt.index)
+            if tt.want != "" {
+                if got != tt.want {
+                    t.Errorf("RankAt(%q, %d) = %q, want %q", tt.ranks, tt.index, got, tt.want)
+                }
+                return
+            }
+            index := max(0, min(tt.index, len(tt.ranks)))
+            if index > 0 && got <= tt.ranks[index-1] {
+                t.Errorf("RankAt(%q, %d) = %q, not after %q", tt.ranks, tt.index, got, tt.ranks[index-1])
+            }
+            if index < len(tt.ranks) && got >= tt.ranks[index] {
+                t.Errorf("RankAt(%q, %d) = %q, not ahead of %q", tt.ranks, tt.index, got, tt.ranks[index])
+            }
+        })
+    }
+}
+
+func TestSpreadRanks(t *testing.T) {
+    for _, n := range []int{0, 1, 2, 35, 36, 100, 1296, 2000} {
+        ranks := SpreadRanks(n)
+        if len(ranks) != n {
+            t.Fatalf("SpreadRanks(%d) returned %d ranks", n, len(ranks))
+        }
+        for i, rank := range ranks {
+            if len(rank) > RankLimit {
+                t.Errorf("SpreadRanks(%d)[%d] = %q, longer than RankLimit", n, i, rank)
+            }
+            if i > 0 && rank <= ranks[i-1] {
+                t.Errorf("SpreadRanks(%d)[%d] = %q, not after %q", n, i, rank, ranks[i-1])
+            }
+            if strings.HasSuffix(rank, rankDigits[:1]) {
+                t.Errorf("SpreadRanks(%d)[%d] = %q, ends in the lowest digit", n, i, rank)
+            }
+        }
+        if n > 0 && len(RankBetween("", ranks[0])) > len(ranks[0])+1 {
+            // Room is left ahead of the first rank
+            t.Errorf("SpreadRanks(%d): no room ahead of %q", n, ranks[0])
+        }
+    }
+}
+
+func TestRanksBetween(t *testing.T) {
+    ranks := RanksBetween("a", "b", 40)
+    if len(ranks) != 40 {
+        t.Fatalf("RanksBetween returned %d ranks, want 40", len(ranks))
+    }
+    for i, rank := range ranks {
+        if rank <= "a" || rank >= "b" {
+            t.Errorf("RanksBetween(%q, %q)[%d] = %q, out of bounds", "a", "b", i, rank)
+        }
+        if i > 0 && rank <= ranks[i-1] {
+            t.Errorf("RanksBetween[%d] = %q, not after %q", i, rank, ranks[i-1])
+        }
+    }
+}
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -10,6 +12,17 @@ type DBInterface interface {
     Query(query string, args ...interface{}) (*sql.Rows, error)
     QueryRow(query string, args ...interface{}) *sql.Row
     Exec(query string, args ...interface{}) (sql.Result, error)
+
+    // Context-aware variants; the repositories use these exclusively so
+    // callers can time out or cancel any query.
+    QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+    QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+    // WithTx runs fn inside a transaction, committing if it returns nil and
+    // rolling back otherwise. Calling WithTx on the tx passed to fn nests
+    // using a savepoint.
+    WithTx(ctx context.Context, fn func(tx DBInterface) error) error
 }
 
 // Board CRUD operations
@@ -21,7 +34,7 @@ func NewBoardRepository(db DBInterface) *BoardRepository {
     return &BoardRepository{db: db}
 }
 
-func (r *BoardRepository) Create(board *Board) error {
+func (r *BoardRepository) Create(ctx context.Context, board *Board) error {
     query := `
         INSERT INTO boards (title, description, created_at, updated_at)
         VALUES (?, ?, ?, ?)
@@ -30,7 +43,7 @@ func (r *BoardRepository) Create(board *Board) error {
     board.CreatedAt = now
     board.UpdatedAt = now
 
-    result, err := r.db.Exec(query, board.Title, board.Description, now, now)
+    result, err := r.db.ExecContext(ctx, query, board.Title, board.Description, now, now)
     if err != nil {
         return err
     }
@@ -44,24 +57,111 @@ func (r *BoardRepository) Create(board *Board) error {
     return nil
 }
 
-func (r *BoardRepository) GetById(id int64) (*Board, error) {
+// CreateWithColumns creates a board together with its initial columns,
+// atomically. On success board.Columns holds the created columns.
+func (r *BoardRepository) CreateWithColumns(ctx context.Context, board *Board, columns []StatusColumn) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        if err := NewBoardRepository(tx).Create(ctx, board); err != nil {
+            return err
+        }
+        columnRepo := NewStatusColumnRepository(tx)
+        created := make([]StatusColumn, len(columns))
+        for i, col := range columns {
+            col.BoardId = board.Id
+            if err := columnRepo.Create(ctx, &col); err != nil {
+                return err
+            }
+            created[i] = col
+        }
+        board.Columns = created
+        return nil
+    })
+}
+
+// Duplicate creates board as a copy of the board with the given id: its
+// columns and live tasks, in the same order. On success board.Columns
+// holds the created columns.
+func (r *BoardRepository) Duplicate(ctx context.Context, id int64, board *Board) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        columns, err := NewStatusColumnRepository(tx).GetByBoardId(ctx, id)
+        if err != nil {
+            return err
+        }
+        if len(columns) == 0 {
+            var exists bool
+            if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM boards WHERE id = ?)`, id).Scan(&exists); err != nil {
+                return err
+            }
+            if !exists {
+                return ErrNotFound
+            }
+        }
+        copies := make([]StatusColumn, len(columns))
+        for i, column := range columns {
+            copies[i] = StatusColumn{Name: column.Name, Position: column.Position, Color: column.Color, Sort: column.Sort}
+        }
+        if err := NewBoardRepository(tx).CreateWithColumns(ctx, board, copies); err != nil {
+            return err
+        }
+
+        query := `
+            INSERT INTO tasks (board_id, status_column_id, title, description, rank, priority, due_date, assignee, tags, created_at, updated_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `
+        taskRepo := NewTaskRepository(tx)
+        for i, column := range columns {
+            tasks, err := taskRepo.GetByColumnId(ctx, column.Id)
+            if err != nil {
+                return err
+            }
+            copied := board.Columns[i]
+            for _, task := range tasks {
+                result, err := tx.ExecContext(ctx, query,
+                    board.Id, copied.Id, task.title, task.description,
+                    task.Rank, task.Priority, task.DueDate, task.Assignee, task.Tags,
+                    board.CreatedAt, board.CreatedAt,
+                )
+                if err != nil {
+                    return err
+                }
+                taskId, err := result.LastInsertId()
+                if err != nil {
+                    return err
+                }
+                event := TaskEvent{TaskId: taskId, BoardId: board.Id, Kind: EventCreated, NewValue: copied.Name}
+                if err := insertEvents(ctx, tx, event); err != nil {
+                    return err
+                }
+                if err := syncTags(ctx, tx, taskId, board.Id, task.Tags); err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    })
+}
+
+func (r *BoardRepository) GetById(ctx context.Context, id int64) (*Board, error) {
     query := `
         SELECT id, title, description, created_at, updated_at
         FROM boards WHERE id = ?
     `
 
     board := &Board{}
-    err := r.db.QueryRow(query, id).Scan(
+    err := r.db.QueryRowContext(ctx, query, id).Scan(
         &board.Id, &board.Title, &board.Description,
         &board.CreatedAt, &board.UpdatedAt,
     )
+    if err == sql.ErrNoRows {
+        return nil, ErrNotFound
+    }
     if err != nil {
         return nil, err
     }
 
     // Load columns
     columnRepo := NewStatusColumnRepository(r.db)
-    columns, err := columnRepo.GetByBoardId(id)
+    columns, err := columnRepo.GetByBoardId(ctx, id)
     if err != nil {
         return nil, err
     }
@@ -69,7 +169,7 @@ func (r *BoardRepository) GetById(id int64) (*Board, error) {
 
     // Load tasks
     taskRepo := NewTaskRepository(r.db)
-    tasks, err := taskRepo.GetByBoardId(id)
+    tasks, err := taskRepo.GetByBoardId(ctx, id)
     if err != nil {
         return nil, err
     }
@@ -78,13 +178,13 @@ func (r *BoardRepository) GetById(id int64) (*Board, error) {
     return board, nil
 }
 
-func (r *BoardRepository) GetAll() ([]Board, error) {
+func (r *BoardRepository) GetAll(ctx context.Context) ([]Board, error) {
     query := `
         SELECT id, title, description, created_at, updated_at
         FROM boards ORDER BY created_at DESC
     `
 
-    rows, err := r.db.Query(query)
+    rows, err := r.db.QueryContext(ctx, query)
     if err != nil {
         return nil, err
     }
@@ -106,7 +206,7 @@ func (r *BoardRepository) GetAll() ([]Board, error) {
     return boards, rows.Err()
 }
 
-func (r *BoardRepository) Update(board *Board) error {
+func (r *BoardRepository) Update(ctx context.Context, board *Board) error {
     query := `
         UPDATE boards
         SET title = ?, description = ?, updated_at = ?
@@ -115,13 +215,13 @@ func (r *BoardRepository) Update(board *Board) error {
     now := time.Now()
     board.UpdatedAt = now
 
-    _, err := r.db.Exec(query, board.Title, board.Description, now, board.Id)
+    _, err := r.db.ExecContext(ctx, query, board.Title, board.Description, now, board.Id)
     return err
 }
 
-func (r *BoardRepository) Delete(id int64) error {
+func (r *BoardRepository) Delete(ctx context.Context, id int64) error {
     query := `DELETE FROM boards WHERE id = ?`
-    _, err := r.db.Exec(query, id)
+    _, err := r.db.ExecContext(ctx, query, id)
     return err
 }
 
@@ -134,35 +234,54 @@ func NewStatusColumnRepository(db DBInterface) *StatusColumnRepository {
     return &StatusColumnRepository{db: db}
 }
 
-func (r *StatusColumnRepository) Create(column *StatusColumn) error {
+func (r *StatusColumnRepository) Create(ctx context.Context, column *StatusColumn) error {
     query := `
-        INSERT INTO status_columns (board_id, name, position, color)
-        VALUES (?, ?, ?, ?)
+        INSERT INTO status_columns (id, board_id, name, position, color, sort)
+        VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?)
     `
 
-    result, err := r.db.Exec(query, column.BoardId, column.Name, column.Position, column.Color)
-    if err != nil {
-        return err
-    }
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        var count int
+        err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM status_columns WHERE board_id = ?`, column.BoardId).Scan(&count)
+        if err != nil {
+            return err
+        }
+        position := max(0, min(column.Position, count))
+
+        shift := `
+            UPDATE status_columns
+            SET position = position + 1
+            WHERE board_id = ? AND position >= ?
+        `
+        if _, err := tx.ExecContext(ctx, shift, column.BoardId, position); err != nil {
+            return err
+        }
 
-    id, err := result.LastInsertId()
-    if err != nil {
-        return err
-    }
+        result, err := tx.ExecContext(ctx, query, column.Id, column.BoardId, column.Name, position, column.Color, column.Sort)
+        if err != nil {
+            return err
+        }
 
-    column.Id = id
-    return nil
+        id, err := result.LastInsertId()
+        if err != nil {
+            return err
+        }
+
+        column.Id = id
+        column.Position = position
+        return nil
+    })
 }
 
-func (r *StatusColumnRepository) GetByBoardId(boardId int64) ([]StatusColumn, error) {
+func (r *StatusColumnRepository) GetByBoardId(ctx context.Context, boardId int64) ([]StatusColumn, error) {
     query := `
-        SELECT id, board_id, name, position, color
+        SELECT id, board_id, name, position, color, sort
         FROM status_columns
         WHERE board_id = ?
         ORDER BY position
     `
 
-    rows, err := r.db.Query(query, boardId)
+    rows, err := r.db.QueryContext(ctx, query, boardId)
     if err != nil {
         return nil, err
     }
@@ -173,7 +292,7 @@ func (r *StatusColumnRepository) GetByBoardId(boardId int64) ([]StatusColumn, er
         column := StatusColumn{}
         err := rows.Scan(
             &column.Id, &column.BoardId, &column.Name,
-            &column.Position, &column.Color,
+            &column.Position, &column.Color, &column.Sort,
         )
         if err != nil {
             return nil, err
@@ -184,21 +303,148 @@ func (r *StatusColumnRepository) GetByBoardId(boardId int64) ([]StatusColumn, er
     return columns, rows.Err()
 }
 
-func (r *StatusColumnRepository) Update(column *StatusColumn) error {
+func (r *StatusColumnRepository) Update(ctx context.Context, column *StatusColumn) error {
     query := `
         UPDATE status_columns
-        SET name = ?, position = ?, color = ?
+        SET name = ?, color = ?, sort = ?
         WHERE id = ?
     `
 
-    _, err := r.db.Exec(query, column.Name, column.Position, column.Color, column.Id)
+    _, err := r.db.ExecContext(ctx, query, column.Name, column.Color, column.Sort, column.Id)
     return err
 }
 
-func (r *StatusColumnRepository) Delete(id int64) error {
-    query := `DELETE FROM status_columns WHERE id = ?`
-    _, err := r.db.Exec(query, id)
-    return err
+// Move puts a column at position, clamped to the board, shifting the
+// columns in between by one.
+func (r *StatusColumnRepository) Move(ctx context.Context, id int64, position int) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        var boardId int64
+        var from, count int
+        err := tx.QueryRowContext(ctx, `SELECT board_id, position FROM status_columns WHERE id = ?`, id).Scan(&boardId, &from)
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err != nil {
+            return err
+        }
+        err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM status_columns WHERE board_id = ?`, boardId).Scan(&count)
+        if err != nil {
+            return err
+        }
+        to := max(0, min(position, count-1))
+
+        shift := `
+            UPDATE status_columns
+            SET position = position - 1
+            WHERE board_id = ? AND position > ? AND position <= ?
+        `
+        low, high := from, to
+        if to < from {
+            shift = `
+                UPDATE status_columns
+                SET position = position + 1
+                WHERE board_id = ? AND position >= ? AND position < ?
+            `
+            low, high = to, from
+        }
+        if _, err := tx.ExecContext(ctx, shift, boardId, low, high); err != nil {
+            return err
+        }
+        _, err = tx.ExecContext(ctx, `UPDATE status_columns SET position = ? WHERE id = ?`, to, id)
+        return err
+    })
+}
+
+// Delete removes a column and its tasks, trashed ones included, along with
+// the board's tags only they carried, then closes the gap it leaves in the
+// board's column positions.
+func (r *StatusColumnRepository) Delete(ctx context.Context, id int64) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        var boardId int64
+        var position int
+        err := tx.QueryRowContext(ctx, `SELECT board_id, position FROM status_columns WHERE id = ?`, id).Scan(&boardId, &position)
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err != nil {
+            return err
+        }
+
+        if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE status_column_id = ?`, id); err != nil {
+            return err
+        }
+        if err := pruneTags(ctx, tx, boardId); err != nil {
+            return err
+        }
+        if _, err := tx.ExecContext(ctx, `DELETE FROM status_columns WHERE id = ?`, id); err != nil {
+            return err
+        }
+
+        query := `
+            UPDATE status_columns
+            SET position = position - 1
+            WHERE board_id = ? AND position > ?
+        `
+        _, err = tx.ExecContext(ctx, query, boardId, position)
+        return err
+    })
+}
+
+// DeleteMovingTasks moves the tasks of a column, trashed ones included, to
+// the end of column targetId, keeping their order, and then deletes the
+// column.
+func (r *StatusColumnRepository) DeleteMovingTasks(ctx context.Context, id, targetId int64) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        var boardId, targetBoardId int64
+        err := tx.QueryRowContext(ctx, `SELECT board_id FROM status_columns WHERE id = ?`, id).Scan(&boardId)
+        if err == nil {
+            err = tx.QueryRowContext(ctx, `SELECT board_id FROM status_columns WHERE id = ?`, targetId).Scan(&targetBoardId)
+        }
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err != nil {
+            return err
+        }
+        if id == targetId || boardId != targetBoardId {
+            return fmt.Errorf("cannot move tasks from column %d to column %d", id, targetId)
+        }
+
+        var last string
+        err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(rank), '') FROM tasks WHERE status_column_id = ?`, targetId).Scan(&last)
+        if err != nil {
+            return err
+        }
+        rows, err := tx.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE status_column_id = ? ORDER BY rank, id`, id)
+        if err != nil {
+            return err
+        }
+        tasks, err := scanTasks(rows)
+        if err != nil {
+            return err
+        }
+
+        query := `
+            UPDATE tasks
+            SET status_column_id = ?, rank = ?, updated_at = ?, version = version + 1
+            WHERE id = ?
+        `
+        now := time.Now()
+        names := columnNames(ctx, tx)
+        for i, rank := range RanksBetween(last, "", len(tasks)) {
+            before := tasks[i]
+            if _, err := tx.ExecContext(ctx, query, targetId, rank, now, before.Id); err != nil {
+                return err
+            }
+            after := before
+            after.StatusColumnId = targetId
+            if err := insertEvents(ctx, tx, TaskChanges(before, after, names)...); err != nil {
+                return err
+            }
+        }
+
+        return NewStatusColumnRepository(tx).Delete(ctx, id)
+    })
 }
 
 // Task CRUD operations
@@ -210,45 +456,71 @@ func NewTaskRepository(db DBInterface) *TaskRepository {
     return &TaskRepository{db: db}
 }
 
-func (r *TaskRepository) Create(task *Task) error {
+func (r *TaskRepository) Create(ctx context.Context, task *Task) error {
     query := `
-        INSERT INTO tasks (board_id, status_column_id, title, description, position, priority, due_date, assignee, tags, created_at, updated_at)
+        INSERT INTO tasks (board_id, status_column_id, title, description, rank, priority, due_date, assignee, tags, created_at, updated_at)
         VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
     now := time.Now()
-    task.CreatedAt = now
-    task.UpdatedAt = now
+    tags := FormatTags(ParseTags(task.Tags))
 
-    result, err := r.db.Exec(query,
-        task.BoardId, task.StatusColumnId, task.title, task.description,
-        task.Position, task.Priority, task.DueDate, task.Assignee, task.Tags,
-        now, now,
-    )
-    if err != nil {
-        return err
-    }
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        rank, err := rankAt(ctx, tx, task.StatusColumnId, 0, task.Position)
+        if err != nil {
+            return err
+        }
 
-    id, err := result.LastInsertId()
-    if err != nil {
-        return err
-    }
+        result, err := tx.ExecContext(ctx, query,
+            task.BoardId, task.StatusColumnId, task.title, task.description,
+            rank, task.Priority, task.DueDate, task.Assignee, tags,
+            now, now,
+        )
+        if err != nil {
+            return err
+        }
 
-    task.Id = id
-    return nil
+        id, err := result.LastInsertId()
+        if err != nil {
+            return err
+        }
+
+        event := TaskEvent{
+            TaskId:   id,
+            BoardId:  task.BoardId,
+            Kind:     EventCreated,
+            NewValue: columnNames(ctx, tx)(task.StatusColumnId),
+        }
+        if err := insertEvents(ctx, tx, event); err != nil {
+            return err
+        }
+        if err := syncTags(ctx, tx, id, task.BoardId, tags); err != nil {
+            return err
+        }
+
+        task.Id = id
+        task.Rank = rank
+        task.Tags = tags
+        task.CreatedAt = now
+        task.UpdatedAt = now
+        task.Version = 1
+        return nil
+    })
 }
 
-func (r *TaskRepository) GetByColumnId(columnId int64) ([]Task, error) {
-    query := `
-        SELECT id, board_id, status_column_id, title, description, position, priority, due_date, assignee, tags, created_at, updated_at
-        FROM tasks
-        WHERE status_column_id = ?
-        ORDER BY position
-    `
+// taskPosition computes a task's index among the live tasks of its column
+// from the ranks, with ties going to the lower id. Table is the name or
+// alias the query gives tasks.
+func taskPosition(table string) string {
+    return `(SELECT COUNT(*) FROM tasks o
+        WHERE o.status_column_id = ` + table + `.status_column_id AND o.deleted_at IS NULL
+          AND (o.rank < ` + table + `.rank OR (o.rank = ` + table + `.rank AND o.id < ` + table + `.id)))`
+}
 
-    rows, err := r.db.Query(query, columnId)
-    if err != nil {
-        return nil, err
-    }
+// taskColumns is the column list every task query selects, in the order
+// scanTasks expects.
+var taskColumns = `id, board_id, status_column_id, title, description, ` + taskPosition("tasks") + `, rank, priority, due_date, assignee, tags, created_at, updated_at, deleted_at, version`
+
+func scanTasks(rows *sql.Rows) ([]Task, error) {
     defer rows.Close()
 
     var tasks []Task
@@ -256,9 +528,9 @@ func (r *TaskRepository) GetByColumnId(columnId int64) ([]Task, error) {
         task := Task{}
         err := rows.Scan(
             &task.Id, &task.BoardId, &task.StatusColumnId,
-            &task.title, &task.description, &task.Position,
+            &task.title, &task.description, &task.Position, &task.Rank,
             &task.Priority, &task.DueDate, &task.Assignee, &task.Tags,
-            &task.CreatedAt, &task.UpdatedAt,
+            &task.CreatedAt, &task.UpdatedAt, &task.DeletedAt, &task.Version,
         )
         if err != nil {
             return nil, err
@@ -269,69 +541,400 @@ func (r *TaskRepository) GetByColumnId(columnId int64) ([]Task, error) {
     return tasks, rows.Err()
 }
 
-func (r *TaskRepository) GetByBoardId(boardId int64) ([]Task, error) {
+// getTask loads a task by id whether or not it is in the trash.
+func getTask(ctx context.Context, db DBInterface, id int64) (*Task, error) {
+    query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ?`
+
+    rows, err := db.QueryContext(ctx, query, id)
+    if err != nil {
+        return nil, err
+    }
+    tasks, err := scanTasks(rows)
+    if err != nil {
+        return nil, err
+    }
+    if len(tasks) == 0 {
+        return nil, ErrNotFound
+    }
+    return &tasks[0], nil
+}
+
+// GetById returns a task, including one that is in the trash.
+func (r *TaskRepository) GetById(ctx context.Context, id int64) (*Task, error) {
+    return getTask(ctx, r.db, id)
+}
+
+func (r *TaskRepository) GetByColumnId(ctx context.Context, columnId int64) ([]Task, error) {
     query := `
-        SELECT id, board_id, status_column_id, title, description, position, priority, due_date, assignee, tags, created_at, updated_at
+        SELECT ` + taskColumns + `
         FROM tasks
-        WHERE board_id = ?
-        ORDER BY status_column_id, position
+        WHERE status_column_id = ? AND deleted_at IS NULL
+        ORDER BY rank, id
     `
 
-    rows, err := r.db.Query(query, boardId)
+    rows, err := r.db.QueryContext(ctx, query, columnId)
     if err != nil {
         return nil, err
     }
-    defer rows.Close()
+    return scanTasks(rows)
+}
 
-    var tasks []Task
-    for rows.Next() {
-        task := Task{}
-        err := rows.Scan(
-            &task.Id, &task.BoardId, &task.StatusColumnId,
-            &task.title, &task.description, &task.Position,
-            &task.Priority, &task.DueDate, &task.Assignee, &task.Tags,
-            &task.CreatedAt, &task.UpdatedAt,
-        )
-        if err != nil {
-            return nil, err
-        }
-        tasks = append(tasks, task)
+func (r *TaskRepository) GetByBoardId(ctx context.Context, boardId int64) ([]Task, error) {
+    query := `
+        SELECT ` + taskColumns + `
+        FROM tasks
+        WHERE board_id = ? AND deleted_at IS NULL
+        ORDER BY status_column_id, rank, id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, boardId)
+    if err != nil {
+        return nil, err
     }
+    return scanTasks(rows)
+}
 
-    return tasks, rows.Err()
+// GetDeletedByBoardId returns the board's trashed tasks, most recently
+// deleted first.
+func (r *TaskRepository) GetDeletedByBoardId(ctx context.Context, boardId int64) ([]Task, error) {
+    query := `
+        SELECT ` + taskColumns + `
+        FROM tasks
+        WHERE board_id = ? AND deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, boardId)
+    if err != nil {
+        return nil, err
+    }
+    return scanTasks(rows)
 }
 
-func (r *TaskRepository) Update(task *Task) error {
+// Update writes task if it is still at task.Version, and fails with a
+// *ConflictError holding the stored task otherwise.
+func (r *TaskRepository) Update(ctx context.Context, task *Task) error {
     query := `
         UPDATE tasks
-        SET status_column_id = ?, title = ?, description = ?, position = ?, priority = ?, due_date = ?, assignee = ?, tags = ?, updated_at = ?
-        WHERE id = ?
+        SET status_column_id = ?, title = ?, description = ?, priority = ?, due_date = ?, assignee = ?, tags = ?, updated_at = ?, version = version + 1
+        WHERE id = ? AND version = ?
     `
     now := time.Now()
+    tags := FormatTags(ParseTags(task.Tags))
+
+    err := r.db.WithTx(ctx, func(tx DBInterface) error {
+        before, err := getTask(ctx, tx, task.Id)
+        if err != nil {
+            return err
+        }
+        if before.Version != task.Version {
+            return &ConflictError{Current: *before}
+        }
+
+        result, err := tx.ExecContext(ctx, query,
+            task.StatusColumnId, task.title, task.description,
+            task.Priority, task.DueDate, task.Assignee, tags,
+            now, task.Id, task.Version,
+        )
+        if err != nil {
+            return err
+        }
+        if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+            if err == nil {
+                err = &ConflictError{Current: *before}
+            }
+            return err
+        }
+
+        if tags != before.Tags {
+            if err := syncTags(ctx, tx, task.Id, before.BoardId, tags); err != nil {
+                return err
+            }
+        }
+
+        after := *task
+        after.BoardId = before.BoardId
+        after.Tags = tags
+        return insertEvents(ctx, tx, TaskChanges(*before, after, columnNames(ctx, tx))...)
+    })
+    if err != nil {
+        return err
+    }
+    task.Tags = tags
     task.UpdatedAt = now
+    task.Version++
+    return nil
+}
 
-    _, err := r.db.Exec(query,
-        task.StatusColumnId, task.title, task.description,
-        task.Position, task.Priority, task.DueDate, task.Assignee, task.Tags,
-        now, task.Id,
-    )
-    return err
+// setDeleted trashes or restores a task, recording kind when it changed.
+func (r *TaskRepository) setDeleted(ctx context.Context, id int64, deletedAt *time.Time, kind string) error {
+    query := `UPDATE tasks SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND (deleted_at IS NULL) = ?`
+
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        result, err := tx.ExecContext(ctx, query, deletedAt, time.Now(), id, deletedAt != nil)
+        if err != nil {
+            return err
+        }
+        changed, err := result.RowsAffected()
+        if err != nil || changed == 0 {
+            return err
+        }
+
+        task, err := getTask(ctx, tx, id)
+        if err != nil {
+            return err
+        }
+        event := TaskEvent{TaskId: id, BoardId: task.BoardId, Kind: kind}
+        return insertEvents(ctx, tx, event)
+    })
 }
 
-func (r *TaskRepository) Delete(id int64) error {
-    query := `DELETE FROM tasks WHERE id = ?`
-    _, err := r.db.Exec(query, id)
-    return err
+// Delete moves a task to the trash. It stays out of every other query
+// until it is restored or purged.
+func (r *TaskRepository) Delete(ctx context.Context, id int64) error {
+    now := time.Now()
+    return r.setDeleted(ctx, id, &now, EventDeleted)
+}
+
+// Restore takes a task back out of the trash.
+func (r *TaskRepository) Restore(ctx context.Context, id int64) error {
+    return r.setDeleted(ctx, id, nil, EventRestored)
 }
 
-func (r *TaskRepository) MoveToColumn(taskId, columnId int64, position int) error {
+// Purge permanently deletes a trashed task.
+func (r *TaskRepository) Purge(ctx context.Context, id int64) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        var boardId int64
+        err := tx.QueryRowContext(ctx, `SELECT board_id FROM tasks WHERE id = ? AND deleted_at IS NOT NULL`, id).Scan(&boardId)
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id); err != nil {
+            return err
+        }
+        // Its task_tags rows went with it
+        return pruneTags(ctx, tx, boardId)
+    })
+}
+
+// PurgeDeletedBefore permanently deletes every task trashed before cutoff
+// and reports how many were removed.
+func (r *TaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+    var purged int64
+    err := r.db.WithTx(ctx, func(tx DBInterface) error {
+        rows, err := tx.QueryContext(ctx, `SELECT DISTINCT board_id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+        if err != nil {
+            return err
+        }
+        var boardIds []int64
+        for rows.Next() {
+            var boardId int64
+            if err := rows.Scan(&boardId); err != nil {
+                rows.Close()
+                return err
+            }
+            boardIds = append(boardIds, boardId)
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return err
+        }
+
+        result, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+        if err != nil {
+            return err
+        }
+        if purged, err = result.RowsAffected(); err != nil {
+            return err
+        }
+        for _, boardId := range boardIds {
+            if err := pruneTags(ctx, tx, boardId); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    return purged, err
+}
+
+// rankAt returns the rank placing a task at index among the live tasks of
+// columnId other than taskId. A rank longer than RankLimit respreads the
+// column first, so db must be a transaction.
+func rankAt(ctx context.Context, db DBInterface, columnId, taskId int64, index int) (string, error) {
+    rank, err := neighbourRank(ctx, db, columnId, taskId, index)
+    if err != nil || len(rank) <= RankLimit {
+        return rank, err
+    }
+    if err := spreadColumn(ctx, db, columnId, taskId); err != nil {
+        return "", err
+    }
+    return neighbourRank(ctx, db, columnId, taskId, index)
+}
+
+// spreadColumn reranks the tasks of columnId other than taskId with
+// SpreadRanks, keeping their order. Trashed tasks are reranked too, so a
+// restore still puts them back among their old neighbours. Only ranks
+// change, so versions are left alone.
+func spreadColumn(ctx context.Context, db DBInterface, columnId, taskId int64) error {
+    rows, err := db.QueryContext(ctx, `
+        SELECT id FROM tasks
+        WHERE status_column_id = ? AND id != ?
+        ORDER BY rank, id
+    `, columnId, taskId)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    var ids []int64
+    for rows.Next() {
+        var id int64
+        if err := rows.Scan(&id); err != nil {
+            return err
+        }
+        ids = append(ids, id)
+    }
+    if err := rows.Err(); err != nil {
+        return err
+    }
+    rows.Close()
+
+    for i, rank := range SpreadRanks(len(ids)) {
+        if _, err := db.ExecContext(ctx, `UPDATE tasks SET rank = ? WHERE id = ?`, rank, ids[i]); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// neighbourRank returns the rank between the neighbours of index among the
+// live tasks of columnId other than taskId.
+func neighbourRank(ctx context.Context, db DBInterface, columnId, taskId int64, index int) (string, error) {
     query := `
-        UPDATE tasks
-        SET status_column_id = ?, position = ?, updated_at = ?
-        WHERE id = ?
+        SELECT rank FROM tasks
+        WHERE status_column_id = ? AND deleted_at IS NULL AND id != ?
+        ORDER BY rank, id
+        LIMIT 2 OFFSET ?
     `
-    now := time.Now()
+    offset := max(index-1, 0)
+    rows, err := db.QueryContext(ctx, query, columnId, taskId, offset)
+    if err != nil {
+        return "", err
+    }
+    defer rows.Close()
 
-    _, err := r.db.Exec(query, columnId, position, now, taskId)
-    return err
-}
\ No newline at end of file
+    var neighbours []string
+    for rows.Next() {
+        var rank string
+        if err := rows.Scan(&rank); err != nil {
+            return "", err
+        }
+        neighbours = append(neighbours, rank)
+    }
+    if err := rows.Err(); err != nil {
+        return "", err
+    }
+    if index == 0 {
+        return RankAt(neighbours, 0), nil
+    }
+    if len(neighbours) == 0 {
+        // Past the end of the column
+        var last string
+        err := db.QueryRowContext(ctx, `
+            SELECT rank FROM tasks
+            WHERE status_column_id = ? AND deleted_at IS NULL AND id != ?
+            ORDER BY rank DESC, id DESC
+            LIMIT 1
+        `, columnId, taskId).Scan(&last)
+        if err != nil && err != sql.ErrNoRows {
+            return "", err
+        }
+        return RankBetween(last, ""), nil
+    }
+    return RankAt(neighbours, 1), nil
+}
+
+// MoveToColumn moves a task into columnId so that it lands at the given
+// index among the column's other tasks. Only the moved task is written.
+func (r *TaskRepository) MoveToColumn(ctx context.Context, taskId, columnId int64, position int) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        before, err := getTask(ctx, tx, taskId)
+        if err != nil {
+            return err
+        }
+
+        rank, err := rankAt(ctx, tx, columnId, taskId, position)
+        if err != nil {
+            return err
+        }
+
+        query := `
+            UPDATE tasks
+            SET status_column_id = ?, rank = ?, updated_at = ?, version = version + 1
+            WHERE id = ?
+        `
+        now := time.Now()
+
+        if _, err := tx.ExecContext(ctx, query, columnId, rank, now, taskId); err != nil {
+            return err
+        }
+        if before.StatusColumnId == columnId {
+            return nil
+        }
+
+        after := *before
+        after.StatusColumnId = columnId
+        return insertEvents(ctx, tx, TaskChanges(*before, after, columnNames(ctx, tx))...)
+    })
+}
+
+// MoveToBoard moves a task to position in a column of any board, taking
+// its history, tags and other fields along.
+func (r *TaskRepository) MoveToBoard(ctx context.Context, taskId, columnId int64, position int) error {
+    return r.db.WithTx(ctx, func(tx DBInterface) error {
+        before, err := getTask(ctx, tx, taskId)
+        if err != nil {
+            return err
+        }
+        var boardId int64
+        err = tx.QueryRowContext(ctx, `SELECT board_id FROM status_columns WHERE id = ?`, columnId).Scan(&boardId)
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("column %d: %w", columnId, ErrNotFound)
+        }
+        if err != nil {
+            return err
+        }
+
+        rank, err := rankAt(ctx, tx, columnId, taskId, position)
+        if err != nil {
+            return err
+        }
+
+        query := `
+            UPDATE tasks
+            SET board_id = ?, status_column_id = ?, rank = ?, updated_at = ?, version = version + 1
+            WHERE id = ?
+        `
+        if _, err := tx.ExecContext(ctx, query, boardId, columnId, rank, time.Now(), taskId); err != nil {
+            return err
+        }
+        // Tags belong to a board, so the task takes on the target board's
+        // tags of the same names
+        if boardId != before.BoardId {
+            if err := syncTags(ctx, tx, taskId, boardId, before.Tags); err != nil {
+                return err
+            }
+            if err := pruneTags(ctx, tx, before.BoardId); err != nil {
+                return err
+            }
+        }
+
+        after := *before
+        after.BoardId = boardId
+        after.StatusColumnId = columnId
+        return insertEvents(ctx, tx, BoardChanges(*before, after, boardTitles(ctx, tx), columnNames(ctx, tx))...)
+    })
+}
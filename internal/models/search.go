@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"strings"
+)
+
+// SearchHit is a task matched by a search, together with where it lives.
+type SearchHit struct {
+    Task       Task
+    BoardTitle string
+    ColumnName string
+    Snippet    string // Matching excerpt of the description, if any
+}
+
+// searchTerms splits a query into lower-cased words.
+func searchTerms(query string) []string {
+    return strings.Fields(strings.ToLower(query))
+}
+
+// ftsQuery turns user input into an FTS5 query matching every word as a
+// prefix, quoting each word so punctuation is never parsed as syntax.
+func ftsQuery(terms []string) string {
+    quoted := make([]string, len(terms))
+    for i, term := range terms {
+        quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+    }
+    return strings.Join(quoted, " ")
+}
+
+// Search returns live tasks across all boards matching every word of
+// query, best matches first. It uses the tasks_fts index when the database
+// has one and falls back to substring matching otherwise.
+func (r *TaskRepository) Search(ctx context.Context, query string, limit int) ([]SearchHit, error) {
+    terms := searchTerms(query)
+    if len(terms) == 0 {
+        return nil, nil
+    }
+
+    hits, err := r.searchIndex(ctx, terms, limit)
+    if err == nil {
+        return hits, nil
+    }
+    if ctx.Err() != nil {
+        return nil, err
+    }
+    // No index in this database or no FTS5 in this build
+    return r.searchLike(ctx, terms, limit)
+}
+
+func (r *TaskRepository) searchIndex(ctx context.Context, terms []string, limit int) ([]SearchHit, error) {
+    query := `
+        SELECT t.id, t.board_id, t.status_column_id, t.title, t.description, ` + taskPosition("t") + `, t.rank, t.priority,
+               t.due_date, t.assignee, t.tags, t.created_at, t.updated_at, t.deleted_at, t.version,
+               b.title, c.name, snippet(tasks_fts, 1, '', '', '…', 8)
+        FROM tasks_fts
+        JOIN tasks t ON t.id = tasks_fts.rowid
+        JOIN boards b ON b.id = t.board_id
+        JOIN status_columns c ON c.id = t.status_column_id
+        WHERE tasks_fts MATCH ? AND t.deleted_at IS NULL
+        ORDER BY bm25(tasks_fts, 10.0, 1.0, 5.0)
+        LIMIT ?
+    `
+    return r.querySearch(ctx, query, ftsQuery(terms), limit)
+}
+
+func (r *TaskRepository) searchLike(ctx context.Context, terms []string, limit int) ([]SearchHit, error) {
+    var where []string
+    var args []interface{}
+    for _, term := range terms {
+        pattern := "%" + term + "%"
+        where = append(where, "(lower(t.title) LIKE ? OR lower(t.description) LIKE ? OR lower(t.tags) LIKE ?)")
+        args = append(args, pattern, pattern, pattern)
+    }
+    query := `
+        SELECT t.id, t.board_id, t.status_column_id, t.title, t.description, ` + taskPosition("t") + `, t.rank, t.priority,
+               t.due_date, t.assignee, t.tags, t.created_at, t.updated_at, t.deleted_at, t.version,
+               b.title, c.name, ''
+        FROM tasks t
+        JOIN boards b ON b.id = t.board_id
+        JOIN status_columns c ON c.id = t.status_column_id
+        WHERE t.deleted_at IS NULL AND ` + strings.Join(where, " AND ") + `
+        ORDER BY lower(t.title) LIKE ? DESC, t.updated_at DESC
+        LIMIT ?
+    `
+    args = append(args, "%"+terms[0]+"%")
+    return r.querySearch(ctx, query, append(args, limit)...)
+}
+
+// GetAssigned returns the live tasks on every board assigned to assignee,
+// compared without case, ordered by board, column and rank.
+func (r *TaskRepository) GetAssigned(ctx context.Context, assignee string) ([]SearchHit, error) {
+    query := `
+        SELECT t.id, t.board_id, t.status_column_id, t.title, t.description, ` + taskPosition("t") + `, t.rank, t.priority,
+               t.due_date, t.assignee, t.tags, t.created_at, t.updated_at, t.deleted_at, t.version,
+               b.title, c.name, ''
+        FROM tasks t
+        JOIN boards b ON b.id = t.board_id
+        JOIN status_columns c ON c.id = t.status_column_id
+        WHERE t.deleted_at IS NULL AND lower(t.assignee) = lower(?)
+        ORDER BY b.id, c.position, t.rank, t.id
+    `
+    return r.querySearch(ctx, query, strings.TrimSpace(assignee))
+}
+
+func (r *TaskRepository) querySearch(ctx context.Context, query string, args ...interface{}) ([]SearchHit, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var hits []SearchHit
+    for rows.Next() {
+        hit := SearchHit{}
+        task := &hit.Task
+        err := rows.Scan(
+            &task.Id, &task.BoardId, &task.StatusColumnId,
+            &task.title, &task.description, &task.Position, &task.Rank,
+            &task.Priority, &task.DueDate, &task.Assignee, &task.Tags,
+            &task.CreatedAt, &task.UpdatedAt, &task.DeletedAt, &task.Version,
+            &hit.BoardTitle, &hit.ColumnName, &hit.Snippet,
+        )
+        if err != nil {
+            return nil, err
+        }
+        hits = append(hits, hit)
+    }
+    return hits, rows.Err()
+}
+
+// MatchScore ranks how well a task matches query for backends without a
+// search index: every word must appear somewhere, and title matches count
+// more than tag matches, which count more than description matches. A score
+// of zero means no match.
+func MatchScore(task Task, query string) int {
+    terms := searchTerms(query)
+    if len(terms) == 0 {
+        return 0
+    }
+    title := strings.ToLower(task.title)
+    description := strings.ToLower(task.description)
+    tags := strings.ToLower(task.Tags)
+
+    score := 0
+    for _, term := range terms {
+        termScore := 0
+        if strings.Contains(title, term) {
+            termScore += 10
+        }
+        if strings.Contains(tags, term) {
+            termScore += 5
+        }
+        if strings.Contains(description, term) {
+            termScore++
+        }
+        if termScore == 0 {
+            return 0
+        }
+        score += termScore
+    }
+    return score
+}
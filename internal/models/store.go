@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by every backend when a requested row does not
+// exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrReadOnly is returned by writes to storage opened read-only.
+var ErrReadOnly = errors.New("storage is read-only")
+
+// BoardStore persists boards. The SQLite implementation is BoardRepository;
+// see internal/memstore for the in-memory and JSON-file backends.
+type BoardStore interface {
+    Create(ctx context.Context, board *Board) error
+    CreateWithColumns(ctx context.Context, board *Board, columns []StatusColumn) error
+
+    // Duplicate creates board as a copy of the board with the given id,
+    // with its columns and live tasks.
+    Duplicate(ctx context.Context, id int64, board *Board) error
+    GetById(ctx context.Context, id int64) (*Board, error)
+    GetAll(ctx context.Context) ([]Board, error)
+    Update(ctx context.Context, board *Board) error
+    Delete(ctx context.Context, id int64) error
+}
+
+// ColumnStore persists the status columns of a board. Positions are kept
+// contiguous from 0: Create and Move shift the columns they pass and Delete
+// closes the gap.
+type ColumnStore interface {
+    // Create inserts column at column.Position, clamped to the board. A
+    // non-zero column.Id recreates a deleted column under its old id.
+    Create(ctx context.Context, column *StatusColumn) error
+    GetByBoardId(ctx context.Context, boardId int64) ([]StatusColumn, error)
+
+    // Update changes a column's name, color and sort; Move changes its
+    // position.
+    Update(ctx context.Context, column *StatusColumn) error
+    Move(ctx context.Context, id int64, position int) error
+
+    // Delete permanently removes a column and its tasks, trashed ones
+    // included, along with the board's tags that only those tasks carried.
+    // DeleteMovingTasks first moves its tasks, trashed ones included, to
+    // the end of column targetId.
+    Delete(ctx context.Context, id int64) error
+    DeleteMovingTasks(ctx context.Context, id, targetId int64) error
+}
+
+// TaskStore persists tasks.
+type TaskStore interface {
+    Create(ctx context.Context, task *Task) error
+    GetByColumnId(ctx context.Context, columnId int64) ([]Task, error)
+    GetByBoardId(ctx context.Context, boardId int64) ([]Task, error)
+    Update(ctx context.Context, task *Task) error
+    MoveToColumn(ctx context.Context, taskId, columnId int64, position int) error
+
+    // MoveToBoard moves a task to a column of any board, keeping its
+    // history.
+    MoveToBoard(ctx context.Context, taskId, columnId int64, position int) error
+
+    // Delete moves a task to the trash; Restore and Purge act on trashed
+    // tasks, which no other query returns.
+    Delete(ctx context.Context, id int64) error
+    Restore(ctx context.Context, id int64) error
+    Purge(ctx context.Context, id int64) error
+    GetDeletedByBoardId(ctx context.Context, boardId int64) ([]Task, error)
+    PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+    // Search matches live tasks on every board, best matches first.
+    Search(ctx context.Context, query string, limit int) ([]SearchHit, error)
+
+    // GetAssigned returns the live tasks on every board assigned to
+    // assignee, ordered by board, column and rank.
+    GetAssigned(ctx context.Context, assignee string) ([]SearchHit, error)
+
+    // GetById returns a task even if it is in the trash; GetEvents returns
+    // its history, oldest first.
+    GetById(ctx context.Context, id int64) (*Task, error)
+    GetEvents(ctx context.Context, taskId int64) ([]TaskEvent, error)
+}
+
+// TagStore persists the tags of each board. Task writes create the tags
+// named in the task's Tags; a tag no task carries is dropped unless it has a
+// color.
+type TagStore interface {
+    // GetByBoardId returns the tags of a board, sorted by name.
+    GetByBoardId(ctx context.Context, boardId int64) ([]Tag, error)
+
+    // SetColor changes the color of a tag; "" gives it the default color.
+    SetColor(ctx context.Context, id int64, color string) error
+}
+
+// Watcher reports whether storage was changed by another process or
+// connection since it was last asked. Changes made through the stores
+// themselves may be reported as well.
+type Watcher interface {
+    Changed(ctx context.Context) (bool, error)
+}
+
+// Stores bundles the stores of a single backend.
+type Stores struct {
+    Boards  BoardStore
+    Columns ColumnStore
+    Tasks   TaskStore
+    Tags    TagStore
+
+    // Watcher is nil when nothing else can change the storage.
+    Watcher Watcher
+
+    // ReadOnly is set when the storage was opened without write access.
+    ReadOnly bool
+
+    // SubstringSearch is set when Search falls back to substring matching
+    // because this build has no full-text index.
+    SubstringSearch bool
+}
+
+// NewSQLStores returns the SQLite-backed stores for db.
+func NewSQLStores(db DBInterface) Stores {
+    stores := Stores{
+        Boards:  NewBoardRepository(db),
+        Columns: NewStatusColumnRepository(db),
+        Tasks:   NewTaskRepository(db),
+        Tags:    NewTagRepository(db),
+    }
+    if watcher, ok := db.(Watcher); ok {
+        stores.Watcher = watcher
+    }
+    return stores
+}
+
+var (
+    _ BoardStore  = (*BoardRepository)(nil)
+    _ ColumnStore = (*StatusColumnRepository)(nil)
+    _ TaskStore   = (*TaskRepository)(nil)
+    _ TagStore    = (*TagRepository)(nil)
+)
@@ -0,0 +1,192 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"kanban/internal/db"
+	"kanban/internal/memstore"
+	"kanban/internal/models"
+)
+
+// backends returns fresh stores of each backend, by name.
+func backends(t *testing.T) map[string]models.Stores {
+    t.Helper()
+    database, err := db.Open(t.TempDir() + "/kanban.db")
+    if err != nil {
+        t.Fatalf("open database: %v", err)
+    }
+    t.Cleanup(func() { database.Close() })
+    return map[string]models.Stores{
+        "sqlite": models.NewSQLStores(database),
+        "memory": memstore.New().Stores(),
+    }
+}
+
+// newBoard creates a board with the given columns and returns them.
+func newBoard(t *testing.T, s models.Stores, names ...string) []models.StatusColumn {
+    t.Helper()
+    ctx := context.Background()
+    columns := make([]models.StatusColumn, len(names))
+    for i, name := range names {
+        columns[i] = models.StatusColumn{Name: name, Position: i}
+    }
+    board := models.Board{Title: "Board"}
+    if err := s.Boards.CreateWithColumns(ctx, &board, columns); err != nil {
+        t.Fatalf("create board: %v", err)
+    }
+    columns, err := s.Columns.GetByBoardId(ctx, board.Id)
+    if err != nil {
+        t.Fatalf("get columns: %v", err)
+    }
+    return columns
+}
+
+func newTask(t *testing.T, s models.Stores, column models.StatusColumn, title string, position int) models.Task {
+    t.Helper()
+    task := models.Task{BoardId: column.BoardId, StatusColumnId: column.Id, Position: position}
+    task.SetTitle(title)
+    if err := s.Tasks.Create(context.Background(), &task); err != nil {
+        t.Fatalf("create %q: %v", title, err)
+    }
+    return task
+}
+
+func columnTitles(t *testing.T, s models.Stores, column models.StatusColumn) []string {
+    t.Helper()
+    tasks, err := s.Tasks.GetByColumnId(context.Background(), column.Id)
+    if err != nil {
+        t.Fatalf("get tasks: %v", err)
+    }
+    titles := make([]string, len(tasks))
+    for i := range tasks {
+        titles[i] = tasks[i].Title()
+    }
+    return titles
+}
+
+func TestTopInsertsRespreadColumn(t *testing.T) {
+    for name, s := range backends(t) {
+        t.Run(name, func(t *testing.T) {
+            ctx := context.Background()
+            columns := newBoard(t, s, "Todo", "Done")
+            trashed := newTask(t, s, columns[0], "trashed", 0)
+            if err := s.Tasks.Delete(ctx, trashed.Id); err != nil {
+                t.Fatalf("delete: %v", err)
+            }
+            var want []string
+            for i := 0; i < 100; i++ {
+                title := string(rune('a' + i%26)) + string(rune('0'+i/26))
+                newTask(t, s, columns[0], title, 0)
+                want = append([]string{title}, want...)
+            }
+            moved := newTask(t, s, columns[1], "moved", 0)
+            if err := s.Tasks.MoveToColumn(ctx, moved.Id, columns[0].Id, 0); err != nil {
+                t.Fatalf("move: %v", err)
+            }
+            want = append([]string{"moved"}, want...)
+
+            if got := columnTitles(t, s, columns[0]); !slices.Equal(got, want) {
+                t.Errorf("column holds %q, want %q", got, want)
+            }
+
+            // The trashed task was reranked along with the rest
+            if err := s.Tasks.Restore(ctx, trashed.Id); err != nil {
+                t.Fatalf("restore: %v", err)
+            }
+            tasks, err := s.Tasks.GetByColumnId(ctx, columns[0].Id)
+            if err != nil {
+                t.Fatalf("get tasks: %v", err)
+            }
+            for _, task := range tasks {
+                if len(task.Rank) > models.RankLimit {
+                    t.Errorf("%q has rank %q, longer than RankLimit", task.Title(), task.Rank)
+                }
+            }
+        })
+    }
+}
+
+func TestDeleteColumnPrunesTags(t *testing.T) {
+    for name, s := range backends(t) {
+        t.Run(name, func(t *testing.T) {
+            ctx := context.Background()
+            columns := newBoard(t, s, "Todo", "Done")
+            doomed := models.Task{BoardId: columns[0].BoardId, StatusColumnId: columns[0].Id, Tags: "gone, kept"}
+            doomed.SetTitle("doomed")
+            trashed := models.Task{BoardId: columns[0].BoardId, StatusColumnId: columns[0].Id, Tags: "trashed"}
+            trashed.SetTitle("trashed")
+            other := models.Task{BoardId: columns[1].BoardId, StatusColumnId: columns[1].Id, Tags: "kept"}
+            other.SetTitle("other")
+            for _, task := range []*models.Task{&doomed, &trashed, &other} {
+                if err := s.Tasks.Create(ctx, task); err != nil {
+                    t.Fatalf("create %q: %v", task.Title(), err)
+                }
+            }
+            if err := s.Tasks.Delete(ctx, trashed.Id); err != nil {
+                t.Fatalf("delete: %v", err)
+            }
+
+            if err := s.Columns.Delete(ctx, columns[0].Id); err != nil {
+                t.Fatalf("delete column: %v", err)
+            }
+            tags, err := s.Tags.GetByBoardId(ctx, columns[0].BoardId)
+            if err != nil {
+                t.Fatalf("get tags: %v", err)
+            }
+            var names []string
+            for _, tag := range tags {
+                names = append(names, tag.Name)
+            }
+            if want := []string{"kept"}; !slices.Equal(names, want) {
+                t.Errorf("board has tags %q, want %q", names, want)
+            }
+        })
+    }
+}
+
+func TestUpdateStaleVersionConflicts(t *testing.T) {
+    for name, s := range backends(t) {
+        t.Run(name, func(t *testing.T) {
+            ctx := context.Background()
+            columns := newBoard(t, s, "Todo")
+            task := newTask(t, s, columns[0], "original", 0)
+
+            // Two sessions read the task at the same version
+            mine, theirs := task, task
+            theirs.SetTitle("theirs")
+            if err := s.Tasks.Update(ctx, &theirs); err != nil {
+                t.Fatalf("first update: %v", err)
+            }
+            mine.SetTitle("mine")
+            err := s.Tasks.Update(ctx, &mine)
+
+            var conflict *models.ConflictError
+            if !errors.As(err, &conflict) {
+                t.Fatalf("stale update returned %v, want a *ConflictError", err)
+            }
+            if !errors.Is(err, models.ErrConflict) {
+                t.Errorf("%v does not match ErrConflict", err)
+            }
+            if conflict.Current.Title() != "theirs" || conflict.Current.Version != theirs.Version {
+                t.Errorf("conflict carries %q at version %d, want %q at version %d",
+                    conflict.Current.Title(), conflict.Current.Version, "theirs", theirs.Version)
+            }
+            got, err := s.Tasks.GetById(ctx, task.Id)
+            if err != nil {
+                t.Fatalf("get task: %v", err)
+            }
+            if got.Title() != "theirs" {
+                t.Errorf("stored title %q after the rejected update, want %q", got.Title(), "theirs")
+            }
+
+            // Retrying at the stored version goes through
+            mine.Version = conflict.Current.Version
+            if err := s.Tasks.Update(ctx, &mine); err != nil {
+                t.Errorf("update at the stored version: %v", err)
+            }
+        })
+    }
+}
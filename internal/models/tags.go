@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ParseTags reads the tag names in a task's Tags: comma-separated, or a
+// JSON array as some older files hold. Names are trimmed, and repeats of a
+// name in any case are dropped.
+func ParseTags(tags string) []string {
+    tags = strings.TrimSpace(tags)
+    var names []string
+    if !strings.HasPrefix(tags, "[") || json.Unmarshal([]byte(tags), &names) != nil {
+        names = strings.Split(tags, ",")
+    }
+
+    var parsed []string
+    for _, name := range names {
+        name = strings.TrimSpace(name)
+        if name != "" && !containsTag(parsed, name) {
+            parsed = append(parsed, name)
+        }
+    }
+    return parsed
+}
+
+// FormatTags returns tag names as stored in a task's Tags.
+func FormatTags(names []string) string {
+    return strings.Join(names, ", ")
+}
+
+// HasTag reports whether tags, as stored in a task's Tags, include name in
+// any case.
+func HasTag(tags, name string) bool {
+    return containsTag(ParseTags(tags), strings.TrimSpace(name))
+}
+
+func containsTag(names []string, name string) bool {
+    for _, n := range names {
+        if strings.EqualFold(n, name) {
+            return true
+        }
+    }
+    return false
+}
+
+// Tag CRUD operations
+type TagRepository struct {
+    db DBInterface
+}
+
+func NewTagRepository(db DBInterface) *TagRepository {
+    return &TagRepository{db: db}
+}
+
+// GetByBoardId returns the tags of a board, sorted by name.
+func (r *TagRepository) GetByBoardId(ctx context.Context, boardId int64) ([]Tag, error) {
+    query := `
+        SELECT id, board_id, name, color
+        FROM tags WHERE board_id = ?
+        ORDER BY name
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, boardId)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var tags []Tag
+    for rows.Next() {
+        tag := Tag{}
+        if err := rows.Scan(&tag.Id, &tag.BoardId, &tag.Name, &tag.Color); err != nil {
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+    return tags, rows.Err()
+}
+
+// SetColor changes the color of a tag; "" gives it the default color.
+func (r *TagRepository) SetColor(ctx context.Context, id int64, color string) error {
+    result, err := r.db.ExecContext(ctx, `UPDATE tags SET color = ? WHERE id = ?`, color, id)
+    if err != nil {
+        return err
+    }
+    if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+        if err == nil {
+            err = ErrNotFound
+        }
+        return err
+    }
+    return nil
+}
+
+// syncTags links a task to the tags of boardId named in its Tags, creating
+// the ones the board does not have yet, and unlinks it from any others.
+func syncTags(ctx context.Context, tx DBInterface, taskId, boardId int64, tags string) error {
+    if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = ?`, taskId); err != nil {
+        return err
+    }
+    for _, name := range ParseTags(tags) {
+        if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (board_id, name) VALUES (?, ?)`, boardId, name); err != nil {
+            return err
+        }
+        query := `
+            INSERT OR IGNORE INTO task_tags (task_id, tag_id)
+            SELECT ?, id FROM tags WHERE board_id = ? AND name = ?
+        `
+        if _, err := tx.ExecContext(ctx, query, taskId, boardId, name); err != nil {
+            return err
+        }
+    }
+    return pruneTags(ctx, tx, boardId)
+}
+
+// pruneTags drops the tags of a board that no task carries anymore, unless
+// they were given a color worth keeping.
+func pruneTags(ctx context.Context, tx DBInterface, boardId int64) error {
+    query := `
+        DELETE FROM tags
+        WHERE board_id = ? AND color = ''
+          AND NOT EXISTS (SELECT 1 FROM task_tags WHERE tag_id = tags.id)
+    `
+    _, err := tx.ExecContext(ctx, query, boardId)
+    return err
+}
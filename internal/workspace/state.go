@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State is what kanban remembers between runs, kept in state.json in the
+// data directory. It is keyed by storage file, so every workspace, and any
+// file opened with --db, remembers its own board.
+type State struct {
+    path string
+
+    // LastBoards maps a storage file to the board last opened in it
+    LastBoards map[string]int64 `json:"last_boards"`
+}
+
+// LoadState reads the state kept in dataDir, returning an empty state when
+// there is none yet.
+func LoadState(dataDir string) (*State, error) {
+    s := &State{path: filepath.Join(dataDir, "state.json")}
+
+    content, err := os.ReadFile(s.path)
+    if os.IsNotExist(err) {
+        return s, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(content, s); err != nil {
+        return nil, fmt.Errorf("%s: %w", s.path, err)
+    }
+    return s, nil
+}
+
+// LastBoard returns the board last opened in the storage file, or 0.
+func (s *State) LastBoard(storage string) int64 {
+    return s.LastBoards[key(storage)]
+}
+
+// SetLastBoard remembers id as the board last opened in the storage file.
+func (s *State) SetLastBoard(storage string, id int64) error {
+    if s.LastBoards[key(storage)] == id {
+        return nil
+    }
+    if s.LastBoards == nil {
+        s.LastBoards = make(map[string]int64)
+    }
+    s.LastBoards[key(storage)] = id
+    return s.save()
+}
+
+// key makes relative --db paths name the same file from any directory.
+func key(storage string) string {
+    if abs, err := filepath.Abs(storage); err == nil {
+        return abs
+    }
+    return storage
+}
+
+// save replaces the state file atomically, so a crash never leaves it half
+// written.
+func (s *State) save() error {
+    content, err := json.MarshalIndent(s, "", "  ")
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(s.path), 0o770); err != nil {
+        return err
+    }
+
+    tmp, err := os.CreateTemp(filepath.Dir(s.path), "state.json.*.tmp")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := tmp.Write(content); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), s.path)
+}
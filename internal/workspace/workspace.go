@@ -0,0 +1,124 @@
+// Package workspace maps named workspaces to separate storage files in the
+// data directory, so personal and team boards can live apart.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Default is the workspace used when none is named. It keeps the storage
+// file that predates workspaces.
+const Default = "default"
+
+var (
+    ErrInvalidName = errors.New("workspace names may only contain letters, digits, '-' and '_'")
+    ErrNotFound    = errors.New("workspace does not exist")
+    ErrExists      = errors.New("workspace already exists")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Workspaces resolves workspace names for one storage backend.
+type Workspaces struct {
+    dir         string // directory holding the non-default workspaces
+    ext         string // storage file extension, e.g. ".db"
+    defaultPath string // file backing the default workspace
+}
+
+// New returns the workspaces kept under dataDir/workspaces with the given
+// file extension. defaultPath is the file of the default workspace.
+func New(dataDir, ext, defaultPath string) *Workspaces {
+    return &Workspaces{
+        dir:         filepath.Join(dataDir, "workspaces"),
+        ext:         ext,
+        defaultPath: defaultPath,
+    }
+}
+
+// Path returns the storage file for the named workspace, whether or not it
+// exists yet.
+func (w *Workspaces) Path(name string) (string, error) {
+    if name == "" || name == Default {
+        return w.defaultPath, nil
+    }
+    if !validName.MatchString(name) {
+        return "", fmt.Errorf("%q: %w", name, ErrInvalidName)
+    }
+    return filepath.Join(w.dir, name+w.ext), nil
+}
+
+// Exists reports whether the named workspace has a storage file. The
+// default workspace always exists.
+func (w *Workspaces) Exists(name string) (bool, error) {
+    if name == "" || name == Default {
+        return true, nil
+    }
+    path, err := w.Path(name)
+    if err != nil {
+        return false, err
+    }
+    if _, err := os.Stat(path); err != nil {
+        if os.IsNotExist(err) {
+            return false, nil
+        }
+        return false, err
+    }
+    return true, nil
+}
+
+// List returns the names of all workspaces, default first.
+func (w *Workspaces) List() ([]string, error) {
+    names := []string{Default}
+
+    entries, err := os.ReadDir(w.dir)
+    if os.IsNotExist(err) {
+        return names, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var others []string
+    for _, entry := range entries {
+        name, ok := strings.CutSuffix(entry.Name(), w.ext)
+        if !ok || entry.IsDir() || !validName.MatchString(name) || name == Default {
+            continue
+        }
+        others = append(others, name)
+    }
+    sort.Strings(others)
+    return append(names, others...), nil
+}
+
+// Remove deletes the named workspace's storage file along with any SQLite
+// sidecar files. Its lock file is left to whoever holds it. The default
+// workspace cannot be removed.
+func (w *Workspaces) Remove(name string) error {
+    if name == "" || name == Default {
+        return fmt.Errorf("the %s workspace cannot be removed", Default)
+    }
+    exists, err := w.Exists(name)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return fmt.Errorf("%q: %w", name, ErrNotFound)
+    }
+
+    path, _ := w.Path(name)
+    if err := os.Remove(path); err != nil {
+        return err
+    }
+    for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+        if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+            return err
+        }
+    }
+    return nil
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
-	"kanban/internal/db"
 	"kanban/internal/models"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -25,14 +28,59 @@ var appLogo = `
 
 func main() {
 	flag.Parse()
-	db, err := db.NewDB("kanban")
+
+	// Ctrl+C cancels whatever database work is in flight instead of
+	// killing the process mid-write.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if flag.NArg() > 0 {
+		if err := runCommand(ctx, cfg, flag.Args()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	stores, _, l, err := openStorage(cfg, true)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	m := NewModel(db)
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	defer l.Release()
+	if cfg.TrashRetentionDays > 0 && !stores.ReadOnly {
+		cutoff := time.Now().AddDate(0, 0, -cfg.TrashRetentionDays)
+		if _, err := stores.Tasks.PurgeDeletedBefore(ctx, cutoff); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	darkBackground = lipgloss.HasDarkBackground()
+	memory, err := openBoardMemory(cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	startBoard := memory.last()
+	if *boardFlag != "" {
+		if startBoard, err = findBoard(ctx, stores.Boards, *boardFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	m := NewModel(ctx, stores, startBoard)
+	m.user = cfg.User
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+	final, err := p.Run()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := memory.remember(openedBoard(final)); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -45,6 +93,8 @@ var (
 	glacierBlue = lipgloss.Color("#325D70")
 	coralRed    = lipgloss.Color("#FF6F59")
 	darkRed     = lipgloss.Color("#771B18")
+	sandYellow  = lipgloss.Color("#F4D35E")
+	mistBlue    = lipgloss.Color("#8EC5D6")
 
 	// Default column colors
 	todoColor       = "#ff6b6b"
@@ -67,6 +117,13 @@ var (
 	inputPaneStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
 			BorderForeground(coralRed)
+
+	errorStyle   = lipgloss.NewStyle().Foreground(coralRed).Bold(true)
+	warningStyle = lipgloss.NewStyle().Foreground(sandYellow)
+	infoStyle    = lipgloss.NewStyle().Foreground(mistBlue)
+
+	statusBarStyle  = lipgloss.NewStyle().Padding(0, 1)
+	statusModeStyle = lipgloss.NewStyle().Background(pineGreen).Bold(true).Padding(0, 1)
 )
 
 func createListDelegate() list.DefaultDelegate {
@@ -91,26 +148,89 @@ type Mode int
 const (
 	Normal Mode = iota
 	Insert
+	Trash
+	Search
+	History
+	Conflict
+	Command
+	Messages
+	Columns
+	Boards
+	MyTasks
+	TagFilter
 )
 
-type Model struct {
-	db *db.TaskDB
+func (mode Mode) String() string {
+	switch mode {
+	case Insert:
+		return "insert"
+	case Trash:
+		return "trash"
+	case Search:
+		return "search"
+	case History:
+		return "history"
+	case Conflict:
+		return "conflict"
+	case Command:
+		return "command"
+	case Messages:
+		return "messages"
+	case Columns:
+		return "columns"
+	case Boards:
+		return "boards"
+	case MyTasks:
+		return "my tasks"
+	case TagFilter:
+		return "tag filter"
+	}
+	return "normal"
+}
 
-	// Repositories (for database operations)
-	boardRepo  *models.BoardRepository
-	columnRepo *models.StatusColumnRepository
-	taskRepo   *models.TaskRepository
+// dbTimeout bounds every database call made from the TUI so a locked
+// database surfaces as an error instead of freezing the board.
+const dbTimeout = 5 * time.Second
 
-	board   models.Board // Contains metadata about the board (for when we have multiple boards)
-	columns []list.Model // UI components derived from board data
+type Model struct {
+	ctx context.Context
+
+	// Stores (for database operations), backed by whichever storage
+	// backend is configured
+	boardRepo  models.BoardStore
+	columnRepo models.ColumnStore
+	taskRepo   models.TaskStore
+	tagRepo    models.TagStore
+	watcher    models.Watcher // nil when nothing else can change the storage
+	readonly   bool           // every mutating key is disabled
+	substring  bool           // search matches substrings, without a full-text index
+	user       string         // who My Tasks shows tasks for
+
+	board      models.Board  // The open board, with its columns
+	columns    []list.Model  // UI components derived from board data
+	columnSeqs map[int64]int // Last command issued by each column's list, by column id
+	tags       boardTags     // Tags of the open board, for badges and completion
+	startBoard int64         // Board to open first; the newest one if 0 or gone
 
 	// UI state
-	inputPane inputPane
-	width     int
-	height    int
-	focused   int
-	mode      Mode
-	err       error
+	inputPane  inputPane
+	trash      trashPane
+	search     searchPane
+	history    historyPane
+	conflict   conflictPane
+	columnPane columnPane
+	boards     boardPane
+	myTasks    myTasksPane
+	tagFilter  tagFilter
+	writes     writeQueue
+	status     statusBar
+	undoStack  undoStack
+	stale      bool // storage changed elsewhere and the board needs reloading
+	width      int
+	height     int
+	focused    int
+	mode       Mode
+	fatal      error // storage could not be opened; the fatal screen offers a retry
 }
 
 type inputPane struct {
@@ -118,6 +238,9 @@ type inputPane struct {
 	listIndex        int
 	titleInput       textinput.Model
 	descriptionInput textinput.Model
+	tagsInput        textinput.Model
+	priority         int
+	dueInput         textinput.Model
 	focused          int
 }
 
@@ -134,71 +257,158 @@ func initInputPane() inputPane {
 	descriptionInput.CharLimit = 500
 	descriptionInput.Width = 90
 
+	tagsInput := textinput.New()
+	tagsInput.Prompt = "Tags: "
+	tagsInput.Placeholder = "comma-separated, Tab to complete..."
+	tagsInput.CharLimit = 200
+	tagsInput.Width = 60
+	tagsInput.ShowSuggestions = true
+
+	dueInput := textinput.New()
+	dueInput.Prompt = "Due: "
+	dueInput.Placeholder = "tomorrow, fri, in 3 days..."
+	dueInput.CharLimit = 30
+	dueInput.Width = 20
+
 	ip := inputPane{
 		titleInput:       titleInput,
 		descriptionInput: descriptionInput,
+		tagsInput:        tagsInput,
+		priority:         models.PriorityLow,
+		dueInput:         dueInput,
 		focused:          0,
 	}
 
 	return ip
 }
 
-func NewModel(database *db.TaskDB) *Model {
-	boardRepo := models.NewBoardRepository(database)
-	columnRepo := models.NewStatusColumnRepository(database)
-	taskRepo := models.NewTaskRepository(database)
+// focus moves the cursor to a field of the form: 0 for the title, 1 for
+// the description, 2 for the tags, 3 for the priority and 4 for the due
+// date.
+func (ip *inputPane) focus(field int) tea.Cmd {
+	ip.focused = field
+	ip.titleInput.Blur()
+	ip.descriptionInput.Blur()
+	ip.tagsInput.Blur()
+	ip.dueInput.Blur()
+	switch field {
+	case 1:
+		return ip.descriptionInput.Focus()
+	case 2:
+		return ip.tagsInput.Focus()
+	case 3:
+		return nil
+	case 4:
+		return ip.dueInput.Focus()
+	}
+	return ip.titleInput.Focus()
+}
+
+// load fills the form with the fields of task.
+func (ip *inputPane) load(task models.Task) {
+	ip.titleInput.SetValue(task.Title())
+	ip.descriptionInput.SetValue(task.Description())
+	ip.tagsInput.SetValue(task.Tags)
+	ip.priority = task.Priority
+	ip.dueInput.SetValue(formatDueDate(task.DueDate))
+}
+
+// clear empties the form for the next task.
+func (ip *inputPane) clear() {
+	ip.titleInput.SetValue("")
+	ip.descriptionInput.SetValue("")
+	ip.tagsInput.SetValue("")
+	ip.priority = models.PriorityLow
+	ip.dueInput.SetValue("")
+}
 
+// priorityView shows the priority field, highlighted while it has the
+// focus.
+func (ip inputPane) priorityView() string {
+	if ip.focused == 3 {
+		return "Priority: < " + priorityName(ip.priority) + " >"
+	}
+	return "Priority: " + priorityName(ip.priority)
+}
+
+// NewModel returns the TUI on the board with id startBoard, or on the
+// newest board if there is no such board.
+func NewModel(ctx context.Context, stores models.Stores, startBoard int64) *Model {
 	m := &Model{
-		db:         database,
-		boardRepo:  boardRepo,
-		columnRepo: columnRepo,
-		taskRepo:   taskRepo,
+		ctx:        ctx,
+		startBoard: startBoard,
+		boardRepo:  stores.Boards,
+		columnRepo: stores.Columns,
+		taskRepo:   stores.Tasks,
+		tagRepo:    stores.Tags,
+		watcher:    stores.Watcher,
+		readonly:   stores.ReadOnly,
+		substring:  stores.SubstringSearch,
 		inputPane:  initInputPane(),
 		focused:    0,
 		mode:       Normal,
 	}
 
-	if err := m.loadBoard(); err != nil {
-		m.err = err
-	}
-
-	if m.err == nil {
-		if err := m.initColumnsFromDB(); err != nil {
-			m.err = err
-		}
-	}
+	m.fatal = m.start()
 	return m
 }
 
+// dbContext returns a context for a single database operation, bounded by
+// dbTimeout and cancelled when the program shuts down.
+func (m *Model) dbContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(m.ctx, dbTimeout)
+}
+
 func (m *Model) initColumnsFromDB() error {
-	if len(m.board.Columns) == 0 {
-		return nil
-	}
+	ctx, cancel := m.dbContext()
+	defer cancel()
 
 	m.columns = make([]list.Model, len(m.board.Columns))
+	if m.idle() {
+		// Versions are fresh again
+		m.writes.versions = nil
+	}
+	tags, err := m.tagRepo.GetByBoardId(ctx, m.board.Id)
+	if err != nil {
+		return err
+	}
+	m.tags = tags
 
 	for i, column := range m.board.Columns {
-		tasks, err := m.taskRepo.GetByColumnId(column.Id)
+		tasks, err := m.taskRepo.GetByColumnId(ctx, column.Id)
 		if err != nil {
 			return err
 		}
 
-		// Convert to list items
-		items := make([]list.Item, len(tasks))
-		for j := range tasks {
-			items[j] = tasks[j]
-		}
-
 		delegate := createListDelegate()
-		lm := list.New(items, delegate, 0, 0)
+		lm := list.New(m.columnItems(column, tasks), delegate, 0, 0)
 		lm.Title = column.Name
+		if column.Sort == models.SortPriority {
+			lm.Title += " ↓!"
+		}
 		lm = styleListModel(lm)
+		lm = styleColumnList(lm, column, m.tags)
 
 		m.columns[i] = lm
 	}
 	return nil
 }
 
+// columnItems returns the tasks of column as its list shows them: those
+// the tag filter lets through, in the column's order.
+func (m *Model) columnItems(column models.StatusColumn, tasks []models.Task) []list.Item {
+	items := make([]list.Item, 0, len(tasks))
+	for j := range tasks {
+		if m.tagFilter.tag == "" || models.HasTag(tasks[j].Tags, m.tagFilter.tag) {
+			items = append(items, tasks[j])
+		}
+	}
+	if column.Sort == models.SortPriority {
+		sortByPriority(items)
+	}
+	return items
+}
+
 // getSelectedTask returns the currently selected task in the focused column, if any
 func (m *Model) getSelectedTask() (models.Task, bool) {
 	if len(m.columns) == 0 || m.focused < 0 || m.focused >= len(m.columns) {
@@ -213,12 +423,15 @@ func (m *Model) getSelectedTask() (models.Task, bool) {
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.watcher != nil {
+		return watchTick()
+	}
 	return nil
 }
 
-func (m *Model) createTask(title, description string) error {
+func (m *Model) createTask(title, description, tags string, priority int, due *time.Time) tea.Cmd {
 	if len(m.board.Columns) == 0 {
-		return fmt.Errorf("no columns available")
+		return m.notify(severityWarning, "No columns available")
 	}
 
 	// Create task in the focused column (or first column if out of bounds)
@@ -228,83 +441,265 @@ func (m *Model) createTask(title, description string) error {
 	}
 
 	task := models.NewTask(title, description)
+	task.Id = m.tempId()
 	task.BoardId = m.board.Id
 	task.StatusColumnId = columnId
+	task.Tags = tags
+	task.Priority = priority
+	task.DueDate = due
+
+	// Show it right away; the create finishes in the background
+	shown := m.columnCmd(m.focused, m.columns[m.focused].InsertItem(0, task))
+	sorted := m.sortColumn(m.focused)
+
+	tasks := m.taskRepo
+	return tea.Batch(shown, sorted, m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		created := task
+		created.Id = 0
+		run := func(ctx context.Context) error { return tasks.Create(ctx, &created) }
+		finish := func(m *Model, err error) tea.Cmd {
+			i, j, shown := m.findTask(task.Id)
+			if err != nil {
+				if shown {
+					return m.columnCmd(i, removeItem(&m.columns[i], j))
+				}
+				return nil
+			}
+			if m.writes.tempIds == nil {
+				m.writes.tempIds = make(map[int64]int64)
+			}
+			m.writes.tempIds[task.Id] = created.Id
+			m.setStoredVersion(created.Id, created.Version)
+			m.undoStack.record(createOp(created.Id))
+			if shown {
+				// Keep any edits made while the create was running
+				item := m.columns[i].Items()[j].(models.Task)
+				item.Id = created.Id
+				item.Version = created.Version
+				item.CreatedAt = created.CreatedAt
+				return m.columnCmd(i, m.columns[i].SetItem(j, item))
+			}
+			return nil
+		}
+		return run, finish
+	}))
+}
 
-	// Save to database
-	if err := m.taskRepo.Create(&task); err != nil {
-		return err
+func (m *Model) updateTask(title, description, tags string, priority int, due *time.Time) tea.Cmd {
+	task, ok := m.getSelectedTask()
+	if !ok {
+		return nil
 	}
+	before := task
+	task.SetTitle(title)
+	task.SetDescription(description)
+	task.Tags = tags
+	task.Priority = priority
+	task.DueDate = due
+	index := m.inputPane.listIndex
+	m.inputPane.listIndex = -1
+	return m.saveTask(before, task, index)
+}
 
-	// Update UI only
-	m.columns[m.focused].InsertItem(0, task)
-
-	return nil
+// saveTask shows task, an edit of before at index in the focused column,
+// and saves it in the background.
+func (m *Model) saveTask(before, task models.Task, index int) tea.Cmd {
+	shown := m.columnCmd(m.focused, m.columns[m.focused].SetItem(index, task))
+	sorted := m.sortColumn(m.focused)
+
+	tasks := m.taskRepo
+	return tea.Batch(shown, sorted, m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		id := m.storedId(task.Id)
+		if id == 0 {
+			return nil, nil
+		}
+		base, updated := before, task
+		base.Id, updated.Id = id, id
+		base.Version = m.storedVersion(id, task.Version)
+		updated.Version = base.Version
+
+		run := func(ctx context.Context) error { return tasks.Update(ctx, &updated) }
+		finish := func(m *Model, err error) tea.Cmd {
+			var conflict *models.ConflictError
+			if errors.As(err, &conflict) {
+				return m.showConflict(base, updated, conflict.Current)
+			}
+			if err != nil {
+				if i, j, ok := m.findTask(id, task.Id); ok {
+					return tea.Batch(m.columnCmd(i, m.columns[i].SetItem(j, base)), m.sortColumn(i))
+				}
+				return nil
+			}
+			m.setStoredVersion(id, updated.Version)
+			m.undoStack.record(editOp(base, updated))
+			return nil
+		}
+		return run, finish
+	}))
 }
 
-func (m *Model) updateTask(title, description string) error {
-	if task, ok := m.getSelectedTask(); ok {
-		task.SetTitle(title)
-		task.SetDescription(description)
-		if err := m.taskRepo.Update(&task); err != nil {
-			task.StatusColumnId = m.board.Columns[m.focused].Id
-			return err
-		} else {
-			m.columns[m.focused].SetItem(m.inputPane.listIndex, task)
+// moveTask moves task from its column to position in the column at index
+// target, which may be the same column, and follows it with the focus. The
+// position is clamped to the column.
+func (m *Model) moveTask(task models.Task, target, position int) tea.Cmd {
+	source, index, ok := m.findTask(task.Id)
+	if !ok {
+		return nil
+	}
+	columnId := m.board.Columns[target].Id
+	moved := task
+	moved.MoveToColumn(columnId)
+
+	removed := m.columnCmd(source, removeItem(&m.columns[source], index))
+	position = max(0, min(position, len(m.columns[target].Items())))
+	moved.Position = position
+	inserted := m.columnCmd(target, m.columns[target].InsertItem(position, moved))
+	m.columns[target].Select(position)
+	m.focused = target
+	sorted := m.sortColumn(target)
+
+	tasks := m.taskRepo
+	return tea.Batch(removed, inserted, sorted, m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		id := m.storedId(task.Id)
+		if id == 0 {
+			return nil, nil
 		}
-		m.inputPane.listIndex = -1
+		original := task
+		original.Id = id
+		original.Position = index
+
+		run := func(ctx context.Context) error { return tasks.MoveToColumn(ctx, id, columnId, position) }
+		finish := func(m *Model, err error) tea.Cmd {
+			if err != nil {
+				if i, j, ok := m.findTask(id, task.Id); ok {
+					original = m.columns[i].Items()[j].(models.Task)
+					original.StatusColumnId = task.StatusColumnId
+					original.Position = index
+				}
+				return m.putBack(original, index)
+			}
+			m.setStoredVersion(id, m.storedVersion(id, task.Version)+1)
+			m.undoStack.record(moveOp(original, columnId, position))
+			return nil
+		}
+		return run, finish
+	}))
+}
+
+// deleteTask moves the selected task to the trash.
+func (m *Model) deleteTask(task models.Task) tea.Cmd {
+	i, index, ok := m.findTask(task.Id)
+	if !ok {
+		return nil
 	}
-	return nil
+	removed := m.columnCmd(i, removeItem(&m.columns[i], index))
+
+	tasks := m.taskRepo
+	return tea.Batch(removed, m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		id := m.storedId(task.Id)
+		if id == 0 {
+			return nil, nil
+		}
+		run := func(ctx context.Context) error { return tasks.Delete(ctx, id) }
+		finish := func(m *Model, err error) tea.Cmd {
+			if err != nil {
+				restored := task
+				restored.Id = id
+				return m.putBack(restored, index)
+			}
+			m.setStoredVersion(id, m.storedVersion(id, task.Version)+1)
+			m.undoStack.record(deleteOp(id))
+			return nil
+		}
+		return run, finish
+	}))
+}
+
+// editTask opens the insert pane on task, which must be selected in the
+// focused column.
+func (m *Model) editTask(task models.Task) tea.Cmd {
+	m.inputPane.load(task)
+	m.mode = Insert
+	m.inputPane.focused = 0
+	m.inputPane.taskId = task.Id
+	m.inputPane.listIndex = m.columns[m.focused].GlobalIndex()
+	return m.inputPane.titleInput.Focus()
 }
 
 func handleInsert(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
-		return m, tea.Quit
+		return m, m.quit()
 	case "esc":
 		m.mode = Normal
 		m.inputPane.titleInput.Blur()
 		m.inputPane.descriptionInput.Blur()
+		m.inputPane.tagsInput.Blur()
+		m.inputPane.dueInput.Blur()
 		return m, nil
 	case "tab":
-		if m.inputPane.focused == 0 {
-			m.inputPane.focused = 1
-			m.inputPane.titleInput.Blur()
-			return m, m.inputPane.descriptionInput.Focus()
-		} else {
-			m.inputPane.focused = 0
-			m.inputPane.descriptionInput.Blur()
-			return m, m.inputPane.titleInput.Focus()
+		tags := &m.inputPane.tagsInput
+		if m.inputPane.focused == 2 && len(tags.CurrentSuggestion()) > len(tags.Value()) {
+			// Complete the tag being typed
+			var cmd tea.Cmd
+			*tags, cmd = tags.Update(msg)
+			tags.SetSuggestions(tagSuggestions(tags.Value(), m.tags.names()))
+			return m, cmd
+		}
+		if m.inputPane.focused == 1 {
+			tags.SetSuggestions(tagSuggestions(tags.Value(), m.tags.names()))
+		}
+		return m, m.inputPane.focus((m.inputPane.focused + 1) % 5)
+	case "+", "-", "left", "right":
+		if m.inputPane.focused == 3 {
+			delta := 1
+			if msg.String() == "-" || msg.String() == "left" {
+				delta = -1
+			}
+			m.inputPane.priority = changePriority(m.inputPane.priority, delta)
+			return m, nil
 		}
 	case "enter":
 		title := m.inputPane.titleInput.Value()
 		description := m.inputPane.descriptionInput.Value()
-		var err error
+		tags := m.canonicalTags(m.inputPane.tagsInput.Value())
+		priority := m.inputPane.priority
+		due, err := models.ParseDue(m.inputPane.dueInput.Value(), time.Now())
+		if err != nil {
+			return m, tea.Batch(m.notify(severityWarning, "%v", err), m.inputPane.focus(4))
+		}
+		var cmd tea.Cmd
 		if m.inputPane.taskId != -1 {
-			err = m.updateTask(title, description)
+			m.addTags(tags)
+			cmd = m.updateTask(title, description, tags, priority, due)
 		} else {
 			if title != "" {
-				err = m.createTask(title, description)
+				m.addTags(tags)
+				cmd = m.createTask(title, description, tags, priority, due)
 			}
 		}
-		if err != nil {
-			m.err = err
-		} else {
-			m.inputPane.titleInput.SetValue("")
-			m.inputPane.descriptionInput.SetValue("")
-			m.inputPane.titleInput.Blur()
-			m.inputPane.descriptionInput.Blur()
-			m.inputPane.focused = 0
-			m.mode = Normal
-		}
-		return m, nil
+		m.inputPane.clear()
+		m.inputPane.titleInput.Blur()
+		m.inputPane.descriptionInput.Blur()
+		m.inputPane.tagsInput.Blur()
+		m.inputPane.dueInput.Blur()
+		m.inputPane.focused = 0
+		m.mode = Normal
+		return m, cmd
 	}
 
 	var cmd tea.Cmd
-	if m.inputPane.focused == 0 {
+	switch m.inputPane.focused {
+	case 0:
 		m.inputPane.titleInput, cmd = m.inputPane.titleInput.Update(msg)
-	} else {
+	case 1:
 		m.inputPane.descriptionInput, cmd = m.inputPane.descriptionInput.Update(msg)
+	case 2:
+		tags := &m.inputPane.tagsInput
+		*tags, cmd = tags.Update(msg)
+		tags.SetSuggestions(tagSuggestions(tags.Value(), m.tags.names()))
+	case 4:
+		m.inputPane.dueInput, cmd = m.inputPane.dueInput.Update(msg)
 	}
 	return m, cmd
 }
@@ -312,13 +707,27 @@ func handleInsert(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
 func handleListInput(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
 	listModel, cmd := m.columns[m.focused].Update(msg)
 	m.columns[m.focused] = listModel
-	return m, cmd
+	return m, m.columnCmd(m.focused, cmd)
 }
 
+// mutatingKeys are the normal mode keys that change the board. In read-only
+// mode they fall through to the list, as any other unbound key does.
+var mutatingKeys = map[string]bool{"i": true, "e": true, "d": true, "<": true, ">": true, "K": true, "J": true, "u": true, "ctrl+r": true, "c": true, "m": true, "A": true, "+": true, "-": true}
+
+// movingKeys are the normal mode keys that place a task among the others of
+// a column, which the tag filter may be hiding.
+var movingKeys = map[string]bool{"<": true, ">": true, "K": true, "J": true}
+
 func handleNormal(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if m.readonly && mutatingKeys[msg.String()] {
+		return handleListInput(msg, m)
+	}
+	if m.tagFilter.tag != "" && movingKeys[msg.String()] && !m.columns[m.focused].SettingFilter() {
+		return m, m.notify(severityWarning, "Clear the tag filter to move tasks")
+	}
 	switch msg.String() {
 	case "ctrl+c", "q":
-		return m, tea.Quit
+		return m, m.quit()
 	case "left", "h":
 		// Move focus to the left column
 		if m.focused > 0 {
@@ -333,50 +742,119 @@ func handleNormal(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
 		if m.focused > 0 {
 			// move the selected task to the column to the left
 			if task, ok := m.getSelectedTask(); ok {
-				task.StatusColumnId = m.board.Columns[m.focused-1].Id
-				if err := m.taskRepo.Update(&task); err != nil {
-					task.StatusColumnId = m.board.Columns[m.focused].Id
-					m.err = err
-				} else {
-					m.columns[m.focused].RemoveItem(m.columns[m.focused].Index())
-					m.columns[m.focused-1].InsertItem(0, task)
-					m.focused--
-				}
+				return m, m.moveTask(task, m.focused-1, m.columns[m.focused].GlobalIndex())
 			}
 		}
 	case ">":
 		if m.focused < len(m.columns)-1 {
 			// move the selected task to the column to the right
 			if task, ok := m.getSelectedTask(); ok {
-				task.StatusColumnId = m.board.Columns[m.focused+1].Id
-				if err := m.taskRepo.Update(&task); err != nil {
-					task.StatusColumnId = m.board.Columns[m.focused].Id
-					m.err = err
-				} else {
-					m.columns[m.focused].RemoveItem(m.columns[m.focused].Index())
-					m.columns[m.focused+1].InsertItem(0, task)
-					m.focused++
-				}
+				return m, m.moveTask(task, m.focused+1, m.columns[m.focused].GlobalIndex())
 			}
 		}
+	case "K", "J":
+		column := &m.columns[m.focused]
+		if column.SettingFilter() {
+			return handleListInput(msg, m)
+		}
+		if column.FilterState() != list.Unfiltered {
+			return m, m.notify(severityWarning, "Clear the filter to reorder tasks")
+		}
+		if m.board.Columns[m.focused].Sort == models.SortPriority {
+			return m, m.notify(severityWarning, "This column is sorted by priority")
+		}
+		// move the selected task up or down within its column
+		target := column.Index() + 1
+		if msg.String() == "K" {
+			target = column.Index() - 1
+		}
+		if task, ok := m.getSelectedTask(); ok && target >= 0 && target < len(column.Items()) {
+			return m, m.moveTask(task, m.focused, target)
+		}
 
 	case "d":
 		if task, ok := m.getSelectedTask(); ok {
-			if err := m.taskRepo.Delete(task.Id); err != nil {
-				m.err = err
-			} else {
-				m.columns[m.focused].RemoveItem(m.columns[m.focused].Index())
+			return m, m.deleteTask(task)
+		}
+	case "+", "-":
+		if !(m.columns[m.focused].SettingFilter()) {
+			delta := 1
+			if msg.String() == "-" {
+				delta = -1
+			}
+			return m, m.raisePriority(delta)
+		}
+		return handleListInput(msg, m)
+	case "t":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.report(m.openTrash())
+		}
+		return handleListInput(msg, m)
+	case "s":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.openSearch()
+		}
+		return handleListInput(msg, m)
+	case "u":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.undo()
+		}
+		return handleListInput(msg, m)
+	case "ctrl+r":
+		return m, m.redo()
+	case "H":
+		if !(m.columns[m.focused].SettingFilter()) {
+			if task, ok := m.getSelectedTask(); ok {
+				return m, m.report(m.openHistory(task))
 			}
+			return m, nil
+		}
+		return handleListInput(msg, m)
+	case ":":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.openCommand()
 		}
+		return handleListInput(msg, m)
+	case "c":
+		if !(m.columns[m.focused].SettingFilter()) {
+			m.openColumns()
+			return m, nil
+		}
+		return handleListInput(msg, m)
+	case "b":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.report(m.openBoards())
+		}
+		return handleListInput(msg, m)
+	case "M":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.openMyTasks()
+		}
+		return handleListInput(msg, m)
+	case "#":
+		if !(m.columns[m.focused].SettingFilter()) {
+			return m, m.openTagFilter()
+		}
+		return handleListInput(msg, m)
+	case "A":
+		if !(m.columns[m.focused].SettingFilter()) {
+			if task, ok := m.getSelectedTask(); ok {
+				return m, m.assignToMe(task)
+			}
+			return m, nil
+		}
+		return handleListInput(msg, m)
+	case "m":
+		if !(m.columns[m.focused].SettingFilter()) {
+			if task, ok := m.getSelectedTask(); ok {
+				return m, m.report(m.openSend(task))
+			}
+			return m, nil
+		}
+		return handleListInput(msg, m)
 	case "e":
 		if task, ok := m.getSelectedTask(); ok {
-			m.inputPane.titleInput.SetValue(task.Title())
-			m.inputPane.descriptionInput.SetValue(task.Description())
-			m.mode = Insert
-			m.inputPane.focused = 0
-			m.inputPane.taskId = task.Id
-			m.inputPane.listIndex = m.columns[m.focused].Index()
-			return m, m.inputPane.titleInput.Focus()
+			return m, m.editTask(task)
 		}
 	case "i":
 		// Enter insert mode
@@ -385,6 +863,7 @@ func handleNormal(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
 			m.inputPane.taskId = -1
 			m.inputPane.listIndex = -1
 			m.inputPane.focused = 0
+			m.inputPane.priority = models.PriorityLow
 			return m, m.inputPane.titleInput.Focus()
 		}
 		return handleListInput(msg, m)
@@ -411,32 +890,98 @@ func (m *Model) handleWindowSize(width, height int) {
 	for i := range m.columns {
 		m.columns[i].SetSize(columnWidth-horizontal, columnHeight-vertical)
 	}
+	if m.mode == Trash {
+		m.resizeTrash()
+	}
+	if m.mode == Search {
+		m.resizeSearch()
+	}
+	if m.mode == History {
+		m.resizeHistory()
+	}
+	if m.mode == Messages {
+		m.resizeMessages()
+	}
+	if m.mode == Boards {
+		m.resizeBoards()
+	}
+	if m.mode == MyTasks {
+		m.resizeMyTasks()
+	}
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.handleWindowSize(msg.Width, msg.Height)
+	case watchTickMsg:
+		return m, m.handleWatchTick()
+	case watchResultMsg:
+		return m, m.handleWatchResult(msg)
+	case writeResultMsg:
+		return m, m.handleWriteResult(msg)
+	case columnMsg:
+		return m, m.handleColumnMsg(msg)
+	case toastExpiredMsg:
+		m.handleToastExpired(msg)
+		return m, nil
 	case tea.KeyMsg:
+		if m.fatal != nil {
+			return handleFatal(msg, &m)
+		}
 		switch m.mode {
 		case Insert:
 			return handleInsert(msg, &m)
 		case Normal:
 			return handleNormal(msg, &m)
+		case Trash:
+			return handleTrash(msg, &m)
+		case Search:
+			return handleSearch(msg, &m)
+		case History:
+			return handleHistory(msg, &m)
+		case Conflict:
+			return handleConflict(msg, &m)
+		case Command:
+			return handleCommand(msg, &m)
+		case Messages:
+			return handleMessages(msg, &m)
+		case Columns:
+			return handleColumns(msg, &m)
+		case Boards:
+			return handleBoards(msg, &m)
+		case MyTasks:
+			return handleMyTasks(msg, &m)
+		case TagFilter:
+			return handleTagFilter(msg, &m)
 		}
 	}
 
+	// Anything else is for the list on screen, as are the matches of a
+	// filter being typed into it
 	var cmd tea.Cmd
-	if len(m.columns) > 0 && m.focused < len(m.columns) {
+	switch {
+	case m.mode == Trash:
+		m.trash.list, cmd = m.trash.list.Update(msg)
+	case m.mode == Boards:
+		m.boards.list, cmd = m.boards.list.Update(msg)
+	case m.mode == MyTasks:
+		m.myTasks.list, cmd = m.myTasks.list.Update(msg)
+	case m.mode == History:
+		m.history.list, cmd = m.history.list.Update(msg)
+	case m.mode == Messages:
+		m.status.messages, cmd = m.status.messages.Update(msg)
+	case len(m.columns) > 0 && m.focused < len(m.columns):
 		// Update the focused column
 		m.columns[m.focused], cmd = m.columns[m.focused].Update(msg)
+		cmd = m.columnCmd(m.focused, cmd)
 	}
 	return m, cmd
 }
 
 func (m Model) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("Error: %v", m.err)
+	if m.fatal != nil {
+		return m.fatalView()
 	}
 	if len(m.columns) == 0 {
 		return "Loading..."
@@ -444,73 +989,92 @@ func (m Model) View() string {
 
 	column_views := make([]string, len(m.columns))
 	for i, col := range m.columns {
-		if i == m.focused {
-			column_views[i] = focusedColumnStyle.Render(col.View())
-		} else {
-			column_views[i] = unfocusedColumnStyle.Render(col.View())
-		}
+		column_views[i] = columnStyleOf(m.board.Columns[i], i == m.focused).Render(col.View())
 	}
 
 	var helpText string
 	var inputPaneView string
 
 	if m.mode == Insert {
-		helpText = "\nInsert Mode: Tab to switch fields, Enter to save, Esc to cancel\n"
-		inputPaneView = m.inputPane.titleInput.View() + m.inputPane.descriptionInput.View()
+		helpText = "\nInsert Mode: Tab to switch fields or complete a tag, +/- or ← → to set the priority, Enter to save, Esc to cancel\n"
+		inputPaneView = m.inputPane.titleInput.View() + m.inputPane.descriptionInput.View() + "\n" + m.inputPane.tagsInput.View() + "  " + m.inputPane.priorityView() + "  " + m.inputPane.dueView()
+	} else if m.mode == Columns {
+		helpText = m.columnsHelp()
+		inputPaneView = ""
+	} else if m.mode == TagFilter {
+		helpText = "\nTag filter: Tab to complete, Enter to show only tasks with the tag, or every task if empty, Esc to cancel\n"
+		inputPaneView = m.tagFilter.input.View()
+	} else if m.readonly {
+		helpText = "\nRead-only: ← → to switch columns, b for boards, M for my tasks, # to filter by tag, s to search all boards, H for task history, t to view trash, :messages for recent errors, q to quit\n"
+		inputPaneView = ""
 	} else {
-		helpText = "\nPress ← → to switch columns, i to add task, d to delete task, q to quit\n"
+		helpText = "\nPress ← → to switch columns, i to add task, d to delete task, < > to move, K/J to reorder, +/- for priority, m to send to another board, A to assign to me, u/ctrl+r to undo/redo, c for columns, b for boards, M for my tasks, # to filter by tag, s to search all boards, H for task history, t to open trash, :messages for recent errors, q to quit\n"
 		inputPaneView = ""
 	}
 
+	if m.writes.quitting {
+		helpText = "\nSaving changes before quitting...\n"
+	}
+
 	titlebarView := titlebarStyle.Render(appLogo)
 	boardView := lipgloss.JoinHorizontal(lipgloss.Center, column_views...) + helpText
+	switch m.mode {
+	case Trash:
+		boardView = m.trashView()
+	case Search:
+		boardView = m.searchView()
+	case History:
+		boardView = m.historyView()
+	case Conflict:
+		boardView = m.conflictView()
+	case Messages:
+		boardView = m.messagesView()
+	case Boards:
+		boardView = m.boardsView()
+	case MyTasks:
+		boardView = m.myTasksView()
+	}
 	if inputPaneView != "" {
 		inputPaneView = inputPaneStyle.Render(inputPaneView)
 	}
-	view := lipgloss.JoinVertical(lipgloss.Center, titlebarView, boardView, inputPaneView)
+	view := lipgloss.JoinVertical(lipgloss.Center, titlebarView, boardView, inputPaneView, m.statusView())
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, view)
 }
 
 func (m *Model) loadBoard() error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+
 	// Try to get the first board, or create a default one
-	boards, err := m.boardRepo.GetAll()
+	boards, err := m.boardRepo.GetAll(ctx)
 	if err != nil {
 		return err
 	}
 
+	if len(boards) == 0 && m.readonly {
+		return errors.New("there are no boards yet; open kanban once without --readonly to create one")
+	}
 	if len(boards) == 0 {
 		// Create a default board with columns
 		board := &models.Board{
 			Title:       "My Kanban Board",
 			Description: "Default board",
 		}
-		if err := m.boardRepo.Create(board); err != nil {
-			return err
-		}
 
-		// Create default columns
-		defaultColumns := []models.StatusColumn{
-			{BoardId: board.Id, Name: "To Do", Position: 0, Color: todoColor},
-			{BoardId: board.Id, Name: "In Progress", Position: 1, Color: inProgressColor},
-			{BoardId: board.Id, Name: "Done", Position: 2, Color: doneColor},
-		}
-
-		for _, col := range defaultColumns {
-			if err := m.columnRepo.Create(&col); err != nil {
-				return err
-			}
-		}
-
-		// Load columns we just created into board state
-		cols, err := m.columnRepo.GetByBoardId(board.Id)
-		if err != nil {
+		// Create default columns in the same transaction as the board
+		if err := m.boardRepo.CreateWithColumns(ctx, board, defaultColumns()); err != nil {
 			return err
 		}
-		board.Columns = cols
 		m.board = *board
 	} else {
-		// Load existing board
-		board, err := m.boardRepo.GetById(boards[0].Id)
+		// Load the requested board, or the newest one
+		id := boards[0].Id
+		for _, board := range boards {
+			if board.Id == m.startBoard {
+				id = board.Id
+			}
+		}
+		board, err := m.boardRepo.GetById(ctx, id)
 		if err != nil {
 			return err
 		}
@@ -520,3 +1084,51 @@ func (m *Model) loadBoard() error {
 	// Tasks are now loaded as part of the board in the repository
 	return nil
 }
+
+// openBoard switches to the board with the given id and rebuilds its
+// columns, focusing the first one.
+func (m *Model) openBoard(id int64) error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	board, err := m.boardRepo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if board.Id != m.board.Id {
+		// Tags belong to a board
+		m.tagFilter.tag = ""
+	}
+	m.board = *board
+	m.focused = 0
+	if err := m.initColumnsFromDB(); err != nil {
+		return err
+	}
+	m.handleWindowSize(m.width, m.height)
+	return nil
+}
+
+// selectTask focuses the column holding the task with the given id and
+// moves the selection onto it, clearing the tag filter if it hides the
+// task, and the column's filter, as the task is selected among all of its
+// items. It reports whether the task was found.
+func (m *Model) selectTask(id int64) bool {
+	for i := range m.columns {
+		for j, item := range m.columns[i].Items() {
+			if task, ok := item.(models.Task); ok && task.Id == id {
+				m.focused = i
+				m.columns[i].ResetFilter()
+				m.columns[i].Select(j)
+				return true
+			}
+		}
+	}
+	if m.tagFilter.tag != "" && m.idle() {
+		m.tagFilter.tag = ""
+		if m.initColumnsFromDB() == nil {
+			m.handleWindowSize(m.width, m.height)
+			return m.selectTask(id)
+		}
+	}
+	return false
+}
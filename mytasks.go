@@ -0,0 +1,250 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= MY TASKS SECTION =========
+
+// myTaskItem shows a task assigned to the user with where it lives, or
+// with its due date when grouped by due date.
+type myTaskItem struct {
+	hit   models.SearchHit
+	byDue bool
+}
+
+func (i myTaskItem) Title() string       { return i.hit.Task.Title() }
+func (i myTaskItem) FilterValue() string { return i.hit.Task.Title() }
+func (i myTaskItem) Description() string {
+	location := i.hit.BoardTitle + " › " + i.hit.ColumnName
+	due := "no due date"
+	if i.hit.Task.DueDate != nil {
+		due = dueLabel(*i.hit.Task.DueDate, time.Now())
+	}
+	if i.byDue {
+		return due + " · " + location
+	}
+	if i.hit.Task.DueDate != nil {
+		return location + " · " + due
+	}
+	return location
+}
+
+type myTasksPane struct {
+	list  list.Model
+	byDue bool // grouped by due date instead of by board and column
+}
+
+// openMyTasks shows the tasks on every board assigned to the configured
+// user.
+func (m *Model) openMyTasks() tea.Cmd {
+	if strings.TrimSpace(m.user) == "" {
+		return m.notify(severityWarning, `Set "user" in the config to see your tasks`)
+	}
+	lm := list.New(nil, createListDelegate(), 0, 0)
+	lm = styleListModel(lm)
+	m.myTasks = myTasksPane{list: lm}
+	if err := m.loadMyTasks(); err != nil {
+		return m.report(err)
+	}
+	m.resizeMyTasks()
+	m.mode = MyTasks
+	return nil
+}
+
+// loadMyTasks refreshes the list, keeping the selection on the same task
+// where it can.
+func (m *Model) loadMyTasks() error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	hits, err := m.taskRepo.GetAssigned(ctx, m.user)
+	if err != nil {
+		return err
+	}
+	if m.myTasks.byDue {
+		// Soonest first, then the ones without a due date
+		sort.SliceStable(hits, func(i, j int) bool {
+			a, b := hits[i].Task.DueDate, hits[j].Task.DueDate
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return a.Before(*b)
+		})
+	}
+
+	selected, _ := m.selectedMyTask()
+	index := min(m.myTasks.list.Index(), max(len(hits)-1, 0))
+	items := make([]list.Item, len(hits))
+	for i := range hits {
+		items[i] = myTaskItem{hits[i], m.myTasks.byDue}
+		if hits[i].Task.Id == selected.Task.Id {
+			index = i
+		}
+	}
+	m.myTasks.list.SetItems(items)
+	m.myTasks.list.Select(index)
+	m.myTasks.list.Title = "My tasks (" + m.user + ")"
+	return nil
+}
+
+func (m *Model) resizeMyTasks() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.myTasks.list.SetSize(m.width-horizontal-2, m.height-18-vertical)
+}
+
+func (m *Model) selectedMyTask() (models.SearchHit, bool) {
+	item, ok := m.myTasks.list.SelectedItem().(myTaskItem)
+	return item.hit, ok
+}
+
+// openMyTask shows the selected task on its board.
+func (m *Model) openMyTask(hit models.SearchHit) error {
+	if hit.Task.BoardId != m.board.Id {
+		if err := m.openBoard(hit.Task.BoardId); err != nil {
+			return err
+		}
+	}
+	if !m.selectTask(hit.Task.Id) {
+		return models.ErrNotFound
+	}
+	m.mode = Normal
+	return nil
+}
+
+// moveMyTask moves a task to the column delta away on its board, keeping
+// its row as < and > do on the board.
+func (m *Model) moveMyTask(hit models.SearchHit, delta int) tea.Cmd {
+	ctx, cancel := m.dbContext()
+	columns, err := m.columnRepo.GetByBoardId(ctx, hit.Task.BoardId)
+	cancel()
+	if err != nil {
+		return m.report(err)
+	}
+	for i, column := range columns {
+		if column.Id != hit.Task.StatusColumnId {
+			continue
+		}
+		if i+delta < 0 || i+delta >= len(columns) {
+			return nil
+		}
+		return m.perform(moveOp(hit.Task, columns[i+delta].Id, hit.Task.Position))
+	}
+	return nil
+}
+
+// changeMyTask queues a change made from My Tasks once every write has
+// landed, so the rows it was picked from are still current. The list is
+// reloaded when the change lands.
+func (m *Model) changeMyTask(change func() tea.Cmd) tea.Cmd {
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	return change()
+}
+
+func handleMyTasks(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if m.myTasks.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.myTasks.list, cmd = m.myTasks.list.Update(msg)
+		return m, cmd
+	}
+
+	key := msg.String()
+	if m.readonly && mutatingKeys[key] {
+		return m, nil
+	}
+	hit, selected := m.selectedMyTask()
+	switch key {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc", "q", "M":
+		m.mode = Normal
+	case "g":
+		m.myTasks.byDue = !m.myTasks.byDue
+		return m, m.report(m.loadMyTasks())
+	case "enter":
+		if selected {
+			return m, m.report(m.openMyTask(hit))
+		}
+	case "e":
+		if !selected {
+			return m, nil
+		}
+		if !m.idle() {
+			return m, m.report(errStillSaving)
+		}
+		if err := m.openMyTask(hit); err != nil {
+			return m, m.report(err)
+		}
+		task, _ := m.getSelectedTask()
+		return m, m.editTask(task)
+	case "<", ">":
+		if selected {
+			delta := 1
+			if key == "<" {
+				delta = -1
+			}
+			return m, m.changeMyTask(func() tea.Cmd { return m.moveMyTask(hit, delta) })
+		}
+	case "d":
+		if selected {
+			return m, m.changeMyTask(func() tea.Cmd { return m.perform(deleteOp(hit.Task.Id)) })
+		}
+	case "A":
+		if selected {
+			return m, m.changeMyTask(func() tea.Cmd { return m.perform(assignOp(hit.Task.Id, hit.Task.Assignee, "")) })
+		}
+	case "u":
+		return m, m.undo()
+	case "ctrl+r":
+		return m, m.redo()
+	default:
+		var cmd tea.Cmd
+		m.myTasks.list, cmd = m.myTasks.list.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m Model) myTasksView() string {
+	help := "\nEnter to open, e to edit, < > to move, d to delete, A to unassign, u/ctrl+r to undo/redo, g to group by " + m.myTasks.groupingToggle() + ", / to filter, esc to go back\n"
+	if m.readonly {
+		help = "\nEnter to open, g to group by " + m.myTasks.groupingToggle() + ", / to filter, esc to go back\n"
+	}
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.myTasks.list.View()) + help
+}
+
+// groupingToggle names the grouping g switches to.
+func (p myTasksPane) groupingToggle() string {
+	if p.byDue {
+		return "board"
+	}
+	return "due date"
+}
+
+// assignToMe assigns the selected task to the user, or unassigns it if it
+// already is.
+func (m *Model) assignToMe(task models.Task) tea.Cmd {
+	if strings.TrimSpace(m.user) == "" {
+		return m.notify(severityWarning, `Set "user" in the config to assign tasks to yourself`)
+	}
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	assignee := m.user
+	if strings.EqualFold(task.Assignee, m.user) {
+		assignee = ""
+	}
+	return m.perform(assignOp(task.Id, task.Assignee, assignee))
+}
+
+// ========= END MY TASKS SECTION =========
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= PERSISTENCE SECTION =========
+
+// errStillSaving is shown when an action needs every write to have landed.
+var errStillSaving = errors.New("still saving, try again in a moment")
+
+// write is a change to storage whose UI effect has already been applied.
+// It is called when the write reaches the front of the queue, so it sees
+// the ids and versions stored by the writes before it, and returns the
+// storage call to run in the background (nil to skip the write) and a
+// finish func that runs in Update with the call's result.
+type write func(m *Model) (run func(ctx context.Context) error, finish func(m *Model, err error) tea.Cmd)
+
+type writeResultMsg struct {
+	err    error
+	finish func(m *Model, err error) tea.Cmd
+}
+
+// writeQueue runs writes one at a time, in the order they were made, so
+// the database always ends up in the state shown on screen.
+type writeQueue struct {
+	pending []write
+	busy    bool
+
+	lastTempId int64
+	tempIds    map[int64]int64 // Stored ids of created tasks, by the temporary id shown meanwhile
+	versions   map[int64]int64 // Stored versions of tasks written since the board was loaded
+
+	quitting bool // Quit once the queue drains
+}
+
+// enqueue adds w to the queue, starting it if nothing else is running.
+func (m *Model) enqueue(w write) tea.Cmd {
+	m.writes.pending = append(m.writes.pending, w)
+	if m.writes.busy {
+		return nil
+	}
+	return m.nextWrite()
+}
+
+func (m *Model) nextWrite() tea.Cmd {
+	for len(m.writes.pending) > 0 {
+		w := m.writes.pending[0]
+		m.writes.pending = m.writes.pending[1:]
+		run, finish := w(m)
+		if run == nil {
+			continue
+		}
+
+		m.writes.busy = true
+		ctx := m.ctx
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+			defer cancel()
+			return writeResultMsg{err: run(ctx), finish: finish}
+		}
+	}
+	if m.writes.quitting {
+		return tea.Quit
+	}
+	return nil
+}
+
+func (m *Model) handleWriteResult(msg writeResultMsg) tea.Cmd {
+	m.writes.busy = false
+	var failed tea.Cmd
+	if msg.err != nil {
+		failed = m.report(fmt.Errorf("could not save, change undone: %w", msg.err))
+	}
+	return tea.Batch(failed, msg.finish(m, msg.err), m.nextWrite())
+}
+
+// save queues a write that is only shown once it lands: done runs in
+// Update after run succeeds.
+func (m *Model) save(run func(ctx context.Context) error, done func(m *Model) tea.Cmd) tea.Cmd {
+	return m.enqueue(func(*Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		finish := func(m *Model, err error) tea.Cmd {
+			if err != nil {
+				return nil
+			}
+			return done(m)
+		}
+		return run, finish
+	})
+}
+
+// idle reports whether every write has finished.
+func (m *Model) idle() bool {
+	return !m.writes.busy && len(m.writes.pending) == 0
+}
+
+// quit exits once every queued write has finished.
+func (m *Model) quit() tea.Cmd {
+	if m.idle() {
+		return tea.Quit
+	}
+	m.writes.quitting = true
+	return nil
+}
+
+// tempId returns an id for a task whose create has not finished yet.
+func (m *Model) tempId() int64 {
+	m.writes.lastTempId--
+	return m.writes.lastTempId
+}
+
+// storedId resolves a temporary id, returning 0 while the task's create is
+// pending or after it failed.
+func (m *Model) storedId(id int64) int64 {
+	if id > 0 {
+		return id
+	}
+	return m.writes.tempIds[id]
+}
+
+// storedVersion returns the version storage holds for a task, as far as
+// this session's writes know, falling back to the version it was read at.
+func (m *Model) storedVersion(id, read int64) int64 {
+	if version, ok := m.writes.versions[id]; ok {
+		return version
+	}
+	return read
+}
+
+func (m *Model) setStoredVersion(id, version int64) {
+	if m.writes.versions == nil {
+		m.writes.versions = make(map[int64]int64)
+	}
+	m.writes.versions[id] = version
+}
+
+// findTask locates the first of ids shown on the board.
+func (m *Model) findTask(ids ...int64) (column, index int, ok bool) {
+	for _, id := range ids {
+		for i := range m.columns {
+			for j, item := range m.columns[i].Items() {
+				if task, isTask := item.(models.Task); isTask && task.Id == id {
+					return i, j, true
+				}
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+// columnIndex returns the index of the column with the given id, or -1.
+func (m *Model) columnIndex(columnId int64) int {
+	for i, column := range m.board.Columns {
+		if column.Id == columnId && i < len(m.columns) {
+			return i
+		}
+	}
+	return -1
+}
+
+// putBack shows task at index in its column again after a failed write.
+func (m *Model) putBack(task models.Task, index int) tea.Cmd {
+	var removed tea.Cmd
+	if i, j, ok := m.findTask(task.Id); ok {
+		removed = m.columnCmd(i, removeItem(&m.columns[i], j))
+	}
+	c := m.columnIndex(task.StatusColumnId)
+	if c < 0 {
+		return removed
+	}
+	index = min(index, len(m.columns[c].Items()))
+	inserted := m.columnCmd(c, m.columns[c].InsertItem(index, task))
+	return tea.Batch(removed, inserted, m.sortColumn(c))
+}
+
+// removeItem removes the item at index among all the items of l and
+// returns the command filtering l again. The list's own RemoveItem takes
+// the index among the filtered items too, and drops the wrong match.
+func removeItem(l *list.Model, index int) tea.Cmd {
+	items := l.Items()
+	if index < 0 || index >= len(items) {
+		return nil
+	}
+	cmd := l.SetItems(slices.Delete(items, index, index+1))
+	keepSelection(l)
+	return cmd
+}
+
+// keepSelection moves the selection of l onto its last item when the one
+// selected is gone.
+func keepSelection(l *list.Model) {
+	if n := len(l.VisibleItems()); n > 0 && l.Index() >= n {
+		l.Select(n - 1)
+	}
+}
+
+// columnMsg carries a message from a command of a column's list, the
+// matches of its filter mostly, back to that column. Left to Update they
+// would reach whichever column has the focus once they arrive.
+type columnMsg struct {
+	columnId int64
+	seq      int
+	msg      tea.Msg
+}
+
+// columnCmd delivers what cmd, a command of the list of column i, returns
+// to that column.
+func (m *Model) columnCmd(i int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil || i < 0 || i >= len(m.board.Columns) {
+		return nil
+	}
+	if m.columnSeqs == nil {
+		m.columnSeqs = make(map[int64]int)
+	}
+	columnId := m.board.Columns[i].Id
+	m.columnSeqs[columnId]++
+	return toColumn(columnId, m.columnSeqs[columnId], cmd)
+}
+
+func toColumn(columnId int64, seq int, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg { return columnMsg{columnId: columnId, seq: seq, msg: cmd()} }
+}
+
+func (m *Model) handleColumnMsg(msg columnMsg) tea.Cmd {
+	i := m.columnIndex(msg.columnId)
+	if i < 0 {
+		return nil
+	}
+	if batch, ok := msg.msg.(tea.BatchMsg); ok {
+		cmds := make([]tea.Cmd, 0, len(batch))
+		for _, cmd := range batch {
+			if cmd != nil {
+				cmds = append(cmds, toColumn(msg.columnId, msg.seq, cmd))
+			}
+		}
+		return tea.Batch(cmds...)
+	}
+	if _, ok := msg.msg.(list.FilterMatchesMsg); ok {
+		// Matches computed for items since changed, or for a filter since
+		// cleared, would select the wrong tasks
+		if msg.seq != m.columnSeqs[msg.columnId] || m.columns[i].FilterState() == list.Unfiltered {
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	m.columns[i], cmd = m.columns[i].Update(msg.msg)
+	keepSelection(&m.columns[i])
+	return m.columnCmd(i, cmd)
+}
+
+// ========= END PERSISTENCE SECTION =========
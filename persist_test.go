@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"kanban/internal/memstore"
+	"kanban/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel returns a model on the default board of stores.
+func newTestModel(t *testing.T, stores models.Stores) *Model {
+	t.Helper()
+	m := NewModel(context.Background(), stores, 0)
+	if m.fatal != nil {
+		t.Fatalf("start: %v", m.fatal)
+	}
+	return m
+}
+
+// settle runs cmd, which must start the write queue, and every write queued
+// behind it, as the program would. Whatever else the last write returns,
+// such as toasts, is dropped.
+func settle(t *testing.T, m *Model, cmd tea.Cmd) {
+	t.Helper()
+	for !m.idle() {
+		if cmd == nil {
+			t.Fatal("writes are queued but none is running")
+		}
+		result, ok := cmd().(writeResultMsg)
+		if !ok {
+			t.Fatal("expected a write to run")
+		}
+		cmd = m.handleWriteResult(result)
+	}
+}
+
+// stored reads task id back from storage.
+func stored(t *testing.T, m *Model, id int64) *models.Task {
+	t.Helper()
+	task, err := m.taskRepo.GetById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get task %d: %v", id, err)
+	}
+	return task
+}
+
+func TestWriteQueueResolvesTempIds(t *testing.T) {
+	m := newTestModel(t, memstore.New().Stores())
+
+	cmd := m.createTask("one", "", "", 0, nil)
+	shown, _ := m.getSelectedTask()
+	if shown.Id >= 0 {
+		t.Fatalf("task shown with id %d while its create runs, want a temporary id", shown.Id)
+	}
+
+	// Edited before the create lands; the edit waits for the stored id
+	edited := shown
+	edited.SetTitle("one, edited")
+	m.saveTask(shown, edited, 0)
+	settle(t, m, cmd)
+
+	task, _ := m.getSelectedTask()
+	if task.Id <= 0 {
+		t.Fatalf("task still shown with id %d after its create landed", task.Id)
+	}
+	if got := m.storedId(shown.Id); got != task.Id {
+		t.Errorf("temporary id resolves to %d, want %d", got, task.Id)
+	}
+	if got := stored(t, m, task.Id); got.Title() != "one, edited" || got.Version != 2 {
+		t.Errorf("stored %q at version %d, want the edit at version 2", got.Title(), got.Version)
+	}
+}
+
+func TestWriteQueueCarriesVersions(t *testing.T) {
+	m := newTestModel(t, memstore.New().Stores())
+	settle(t, m, m.createTask("one", "", "", 0, nil))
+
+	// The list keeps the version the task was shown at, so each save
+	// relies on the version the one before it stored
+	for _, title := range []string{"two", "three", "four"} {
+		task, _ := m.getSelectedTask()
+		edited := task
+		edited.SetTitle(title)
+		settle(t, m, m.saveTask(task, edited, 0))
+	}
+	task, _ := m.getSelectedTask()
+	if got := stored(t, m, task.Id); got.Title() != "four" || got.Version != 4 {
+		t.Errorf("stored %q at version %d, want %q at version 4", got.Title(), got.Version, "four")
+	}
+	if got := m.storedVersion(task.Id, task.Version); got != 4 {
+		t.Errorf("stored version known as %d, want 4", got)
+	}
+	if m.mode == Conflict {
+		t.Error("own saves were reported as a conflict")
+	}
+}
+
+// failingMoves is a task store whose moves always fail.
+type failingMoves struct {
+	models.TaskStore
+}
+
+var errMoveFailed = errors.New("move failed")
+
+func (failingMoves) MoveToColumn(ctx context.Context, taskId, columnId int64, position int) error {
+	return errMoveFailed
+}
+
+func TestWriteQueuePutsBackFailedMove(t *testing.T) {
+	stores := memstore.New().Stores()
+	stores.Tasks = failingMoves{stores.Tasks}
+	m := newTestModel(t, stores)
+	settle(t, m, m.createTask("one", "", "", 0, nil))
+	settle(t, m, m.createTask("two", "", "", 0, nil))
+
+	m.columns[0].Select(1)
+	task, _ := m.getSelectedTask()
+	cmd := m.moveTask(task, 1, 0)
+	if _, _, ok := m.findTask(task.Id); !ok || len(m.columns[1].Items()) != 1 {
+		t.Fatal("task not shown in the target column while its move runs")
+	}
+	settle(t, m, cmd)
+
+	if len(m.columns[1].Items()) != 0 {
+		t.Errorf("target column still shows %d tasks after the move failed", len(m.columns[1].Items()))
+	}
+	i, j, ok := m.findTask(task.Id)
+	if !ok || i != 0 || j != 1 {
+		t.Errorf("task put back at column %d, row %d, want column 0, row 1", i, j)
+	}
+	if len(m.undoStack.done) != 2 {
+		t.Errorf("%d operations recorded, want only the two creates", len(m.undoStack.done))
+	}
+}
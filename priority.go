@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= PRIORITY SECTION =========
+
+// priorityNames are the names of the priorities, by value.
+var priorityNames = map[int]string{
+	models.PriorityLow:    "low",
+	models.PriorityMedium: "medium",
+	models.PriorityHigh:   "high",
+	models.PriorityUrgent: "urgent",
+}
+
+// priorityName returns the name of a priority, or the number for one
+// written by something else.
+func priorityName(priority int) string {
+	if name, ok := priorityNames[priority]; ok {
+		return name
+	}
+	return strconv.Itoa(priority)
+}
+
+// priorityOf names a priority as history records it, a number.
+func priorityOf(value string) string {
+	if priority, err := strconv.Atoi(value); err == nil {
+		return priorityName(priority)
+	}
+	return value
+}
+
+// priorityMarker is drawn before the title of a card. Low priority tasks,
+// most of them, have none.
+func priorityMarker(priority int) string {
+	switch {
+	case priority >= models.PriorityUrgent:
+		return "!!! "
+	case priority == models.PriorityHigh:
+		return "!! "
+	case priority == models.PriorityMedium:
+		return "! "
+	}
+	return ""
+}
+
+// changePriority returns priority raised by delta levels, kept within low
+// and urgent.
+func changePriority(priority, delta int) int {
+	return max(models.PriorityLow, min(priority+delta, models.PriorityUrgent))
+}
+
+// cardTask is a task as the card delegate draws it, with its priority
+// marker before the title and when it is due before the description.
+type cardTask struct {
+	models.Task
+	marker string
+	due    string
+}
+
+func (t cardTask) Title() string { return t.marker + t.Task.Title() }
+
+func (t cardTask) Description() string {
+	if t.due == "" || t.Task.Description() == "" {
+		return t.due + t.Task.Description()
+	}
+	return t.due + " · " + t.Task.Description()
+}
+
+// renderCard draws a task with the default delegate. The marker is left out
+// while the list is filtered, as the matches highlighted are in the title
+// alone.
+func renderCard(d list.DefaultDelegate, w io.Writer, m list.Model, index int, item list.Item) {
+	task, ok := item.(models.Task)
+	if !ok {
+		d.Render(w, m, index, item)
+		return
+	}
+	now := time.Now()
+	card := cardTask{Task: task}
+	if m.FilterState() == list.Unfiltered {
+		card.marker = priorityMarker(task.Priority)
+	}
+	if task.DueDate != nil {
+		card.due = dueLabel(*task.DueDate, now)
+	}
+	highlightDue(d, task.DueDate, now).Render(w, m, index, card)
+}
+
+// sortColumn puts the tasks of a column sorted by priority back in order
+// after one changed, keeping the selection on the same task.
+func (m *Model) sortColumn(i int) tea.Cmd {
+	if i < 0 || i >= len(m.columns) || m.board.Columns[i].Sort != models.SortPriority {
+		return nil
+	}
+	column := &m.columns[i]
+	selected, _ := column.SelectedItem().(models.Task)
+	items := column.Items()
+	sortByPriority(items)
+	cmd := column.SetItems(items)
+	for j, item := range items {
+		if item.(models.Task).Id == selected.Id {
+			column.Select(j)
+		}
+	}
+	return m.columnCmd(i, cmd)
+}
+
+// sortByPriority orders tasks most pressing first, keeping the order they
+// were placed in among equals.
+func sortByPriority(items []list.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].(models.Task).Priority > items[j].(models.Task).Priority
+	})
+}
+
+// raisePriority changes the priority of the selected task by delta.
+func (m *Model) raisePriority(delta int) tea.Cmd {
+	task, ok := m.getSelectedTask()
+	if !ok {
+		return nil
+	}
+	priority := changePriority(task.Priority, delta)
+	if priority == task.Priority {
+		return m.notify(severityInfo, "Already %s priority", priorityName(priority))
+	}
+	before := task
+	task.Priority = priority
+	return m.saveTask(before, task, m.columns[m.focused].GlobalIndex())
+}
+
+// ========= END PRIORITY SECTION =========
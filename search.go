@@ -0,0 +1,136 @@
+package main
+
+import (
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= SEARCH SECTION =========
+
+// searchLimit caps how many hits the search overlay shows.
+const searchLimit = 50
+
+// searchItem shows a hit with the board and column it lives in.
+type searchItem struct {
+	hit models.SearchHit
+}
+
+func (i searchItem) Title() string       { return i.hit.Task.Title() }
+func (i searchItem) FilterValue() string { return i.hit.Task.Title() }
+func (i searchItem) Description() string {
+	location := i.hit.BoardTitle + " › " + i.hit.ColumnName
+	if i.hit.Snippet != "" {
+		return location + " · " + i.hit.Snippet
+	}
+	return location
+}
+
+type searchPane struct {
+	input   textinput.Model
+	results list.Model
+	query   string
+}
+
+// openSearch shows the global search overlay with an empty query.
+func (m *Model) openSearch() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = "words in titles, descriptions or tags..."
+	input.CharLimit = 100
+
+	results := list.New(nil, createListDelegate(), 0, 0)
+	results.Title = "Results"
+	results.SetFilteringEnabled(false)
+	results = styleListModel(results)
+
+	m.search = searchPane{input: input, results: results}
+	m.resizeSearch()
+	m.mode = Search
+	return m.search.input.Focus()
+}
+
+func (m *Model) resizeSearch() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.search.input.Width = m.width - horizontal - 12
+	height := m.height - 19 - vertical
+	if m.substring {
+		// Room for the note on substring matching
+		height--
+	}
+	m.search.results.SetSize(m.width-horizontal-2, height)
+}
+
+// runSearch refreshes the results when the query has changed.
+func (m *Model) runSearch() error {
+	query := m.search.input.Value()
+	if query == m.search.query {
+		return nil
+	}
+	m.search.query = query
+
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	hits, err := m.taskRepo.Search(ctx, query, searchLimit)
+	if err != nil {
+		return err
+	}
+
+	items := make([]list.Item, len(hits))
+	for i := range hits {
+		items[i] = searchItem{hits[i]}
+	}
+	m.search.results.SetItems(items)
+	m.search.results.Select(0)
+	return nil
+}
+
+// jumpToSearchHit opens the selected hit's board and selects the task.
+func (m *Model) jumpToSearchHit() error {
+	item, ok := m.search.results.SelectedItem().(searchItem)
+	if !ok {
+		return nil
+	}
+	if item.hit.Task.BoardId != m.board.Id {
+		if err := m.openBoard(item.hit.Task.BoardId); err != nil {
+			return err
+		}
+	}
+	m.selectTask(item.hit.Task.Id)
+	m.mode = Normal
+	return nil
+}
+
+func handleSearch(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc":
+		m.mode = Normal
+		return m, nil
+	case "enter":
+		return m, m.report(m.jumpToSearchHit())
+	case "up", "down", "ctrl+p", "ctrl+n", "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.search.results, cmd = m.search.results.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	return m, tea.Batch(cmd, m.report(m.runSearch()))
+}
+
+func (m Model) searchView() string {
+	help := "\nType to search every board, ↑ ↓ to pick, Enter to jump to the task, Esc to go back\n"
+	if m.substring {
+		help = "\nMatching substrings: this build has no full-text index (build with -tags sqlite_fts5 for ranked results)" + help
+	}
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.search.input.View()+"\n\n"+m.search.results.View()) + help
+}
+
+// ========= END SEARCH SECTION =========
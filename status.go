@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ========= STATUS SECTION =========
+
+// messageLimit caps how many messages :messages keeps.
+const messageLimit = 100
+
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityWarning:
+		return "warning"
+	case severityError:
+		return "error"
+	}
+	return "info"
+}
+
+// toastDuration is how long a toast of this severity stays up. Errors stay
+// longest so they can be read; all of them remain in :messages.
+func (s severity) toastDuration() time.Duration {
+	switch s {
+	case severityWarning:
+		return 5 * time.Second
+	case severityError:
+		return 10 * time.Second
+	}
+	return 3 * time.Second
+}
+
+func (s severity) style() lipgloss.Style {
+	switch s {
+	case severityWarning:
+		return warningStyle
+	case severityError:
+		return errorStyle
+	}
+	return infoStyle
+}
+
+type statusMessage struct {
+	text     string
+	severity severity
+	at       time.Time
+}
+
+// statusBar is the line under the board. It shows where the user is and
+// the latest toast, and keeps a log of recent messages.
+type statusBar struct {
+	log     []statusMessage
+	toast   *statusMessage
+	toastId int
+
+	command  textinput.Model // The : command line, shown in place of the toast
+	messages list.Model      // The :messages overlay
+}
+
+type toastExpiredMsg struct {
+	id int
+}
+
+// notify shows a toast and logs it for :messages. A message repeating the
+// last one only refreshes its time, so a failure seen on every tick is
+// logged once.
+func (m *Model) notify(level severity, format string, args ...any) tea.Cmd {
+	msg := statusMessage{text: fmt.Sprintf(format, args...), severity: level, at: time.Now()}
+	if n := len(m.status.log); n > 0 && m.status.log[n-1].text == msg.text && m.status.log[n-1].severity == level {
+		m.status.log[n-1].at = msg.at
+	} else {
+		m.status.log = append(m.status.log, msg)
+	}
+	if len(m.status.log) > messageLimit {
+		m.status.log = m.status.log[len(m.status.log)-messageLimit:]
+	}
+
+	m.status.toast = &msg
+	m.status.toastId++
+	id := m.status.toastId
+	return tea.Tick(level.toastDuration(), func(time.Time) tea.Msg { return toastExpiredMsg{id} })
+}
+
+// report shows err as a toast. Errors the user can simply retry, such as
+// writes while saving or on read-only storage, are warnings.
+func (m *Model) report(err error) tea.Cmd {
+	if err == nil {
+		return nil
+	}
+	level := severityError
+	if errors.Is(err, errStillSaving) || errors.Is(err, models.ErrReadOnly) {
+		level = severityWarning
+	}
+	return m.notify(level, "%v", err)
+}
+
+func (m *Model) handleToastExpired(msg toastExpiredMsg) {
+	if msg.id == m.status.toastId {
+		m.status.toast = nil
+	}
+}
+
+func (m Model) statusView() string {
+	left := statusModeStyle.Render(strings.ToUpper(m.mode.String())) + " " + m.board.Title
+	if m.readonly {
+		left += " · read-only"
+	}
+	if m.tagFilter.tag != "" {
+		left += " · #" + m.tagFilter.tag
+	}
+	if pending := len(m.writes.pending); m.writes.busy {
+		left += fmt.Sprintf(" · saving %d…", pending+1)
+	}
+
+	var right string
+	if m.mode == Command {
+		right = m.status.command.View()
+	} else if toast := m.status.toast; toast != nil {
+		right = toast.severity.style().Render(toast.text)
+	}
+
+	gap := max(m.width-lipgloss.Width(left)-lipgloss.Width(right)-2, 1)
+	return statusBarStyle.Width(m.width).Render(left + strings.Repeat(" ", gap) + right)
+}
+
+// openCommand shows the : command line in the status bar.
+func (m *Model) openCommand() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = ":"
+	input.CharLimit = 50
+	input.Width = 30
+	m.status.command = input
+	m.mode = Command
+	return m.status.command.Focus()
+}
+
+func handleCommand(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc":
+		m.mode = Normal
+		return m, nil
+	case "enter":
+		m.mode = Normal
+		return m, m.runCommand(strings.TrimSpace(m.status.command.Value()))
+	}
+	var cmd tea.Cmd
+	m.status.command, cmd = m.status.command.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) runCommand(command string) tea.Cmd {
+	switch command {
+	case "":
+		return nil
+	case "messages", "mes":
+		m.openMessages()
+		return nil
+	case "q", "quit":
+		return m.quit()
+	}
+	if args, ok := strings.CutPrefix(command, "tag "); ok {
+		return m.colorTag(strings.TrimSpace(args))
+	}
+	return m.notify(severityWarning, "Unknown command :%s", command)
+}
+
+// messageItem shows one logged message in :messages.
+type messageItem struct {
+	msg statusMessage
+}
+
+func (i messageItem) Title() string       { return i.msg.text }
+func (i messageItem) FilterValue() string { return i.msg.text }
+func (i messageItem) Description() string {
+	return i.msg.severity.String() + " · " + i.msg.at.Format("15:04:05")
+}
+
+// openMessages shows the logged messages, newest first.
+func (m *Model) openMessages() {
+	log := m.status.log
+	items := make([]list.Item, len(log))
+	for i := range log {
+		items[len(log)-1-i] = messageItem{log[i]}
+	}
+	lm := list.New(items, createListDelegate(), 0, 0)
+	lm.Title = "Messages"
+	lm = styleListModel(lm)
+
+	m.status.messages = lm
+	m.resizeMessages()
+	m.mode = Messages
+}
+
+func (m *Model) resizeMessages() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.status.messages.SetSize(m.width-horizontal-2, m.height-18-vertical)
+}
+
+func handleMessages(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if m.status.messages.SettingFilter() {
+		var cmd tea.Cmd
+		m.status.messages, cmd = m.status.messages.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc", "q":
+		m.mode = Normal
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.status.messages, cmd = m.status.messages.Update(msg)
+	return m, cmd
+}
+
+func (m Model) messagesView() string {
+	help := "\n↑ ↓ to scroll, / to filter, esc to go back\n"
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.status.messages.View()) + help
+}
+
+// start loads the board the TUI opens on. It fails only when storage is
+// unusable, and the failure is shown on the fatal screen.
+func (m *Model) start() error {
+	if err := m.loadBoard(); err != nil {
+		return err
+	}
+	if err := m.initColumnsFromDB(); err != nil {
+		return err
+	}
+	if m.watcher != nil {
+		// Only changes made after the board was loaded count
+		ctx, cancel := m.dbContext()
+		defer cancel()
+		m.watcher.Changed(ctx)
+	}
+	return nil
+}
+
+func handleFatal(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "r":
+		m.fatal = m.start()
+		if m.fatal == nil {
+			m.handleWindowSize(m.width, m.height)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) fatalView() string {
+	body := errorStyle.Render("Could not open the board") + "\n\n" +
+		m.fatal.Error() + "\n\n" +
+		"r to retry, q to quit"
+	view := lipgloss.JoinVertical(lipgloss.Center,
+		titlebarStyle.Render(appLogo),
+		focusedColumnStyle.Width(min(m.width-2, 80)).Render(body))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, view)
+}
+
+// ========= END STATUS SECTION =========
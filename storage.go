@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kanban/internal/config"
+	"kanban/internal/db"
+	"kanban/internal/lock"
+	"kanban/internal/memstore"
+	"kanban/internal/models"
+	"kanban/internal/workspace"
+)
+
+// ========= STORAGE SECTION =========
+
+const appName = "kanban"
+
+var (
+	dbFlag        = flag.String("db", "", "storage file to open (overrides $KANBAN_DB and --workspace)")
+	workspaceFlag = flag.String("workspace", "", "named workspace to open")
+	readonlyFlag  = flag.Bool("readonly", false, "open the board without changing it, even while another instance has it open")
+)
+
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath(appName)
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(path)
+}
+
+// workspaces returns the workspaces of the configured backend. Each backend
+// keeps its own files, so sqlite and json workspaces never collide.
+func workspaces(cfg *config.Config) *workspace.Workspaces {
+	dataDir := db.DataDir(appName)
+	if cfg.Backend == config.BackendJSON {
+		defaultPath := cfg.JSONPath
+		if defaultPath == "" {
+			defaultPath = filepath.Join(dataDir, appName+".json")
+		}
+		return workspace.New(dataDir, ".json", defaultPath)
+	}
+	return workspace.New(dataDir, ".db", db.DefaultPath(appName))
+}
+
+// storagePath picks the storage file to open: --db first, then $KANBAN_DB,
+// then the file of the selected (or default) workspace.
+func storagePath(cfg *config.Config) (string, error) {
+	if *dbFlag != "" {
+		if *workspaceFlag != "" {
+			return "", fmt.Errorf("--db and --workspace cannot be used together")
+		}
+		return *dbFlag, nil
+	}
+	if env := os.Getenv("KANBAN_DB"); env != "" && *workspaceFlag == "" {
+		return env, nil
+	}
+
+	ws := workspaces(cfg)
+	exists, err := ws.Exists(*workspaceFlag)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("workspace %q does not exist; create it with `%s workspaces create %s`", *workspaceFlag, appName, *workspaceFlag)
+	}
+	return ws.Path(*workspaceFlag)
+}
+
+// lockStorage takes the single-writer lock of the storage file at path,
+// explaining who holds it if another instance does.
+func lockStorage(path string) (*lock.Lock, error) {
+	lockPath := path + ".lock"
+	l, err := lock.Acquire(lockPath)
+	if errors.Is(err, lock.ErrLocked) {
+		holder := "another kanban"
+		if pid := lock.Holder(lockPath); pid != 0 {
+			holder = fmt.Sprintf("another kanban (pid %d)", pid)
+		}
+		return nil, fmt.Errorf("%s is already open in %s; close it first or start with --readonly", path, holder)
+	}
+	return l, err
+}
+
+// openStorage opens the backend selected in cfg at the location chosen by
+// the command line. With exclusive set it also takes the storage lock, which
+// the caller must release; --readonly opens without a lock instead. The
+// returned TaskDB is nil unless the SQLite backend is in use.
+func openStorage(cfg *config.Config, exclusive bool) (models.Stores, *db.TaskDB, *lock.Lock, error) {
+	if cfg.Backend == config.BackendMemory {
+		return memstore.New().Stores(), nil, nil, nil
+	}
+	path, err := storagePath(cfg)
+	if err != nil {
+		return models.Stores{}, nil, nil, err
+	}
+	if *readonlyFlag {
+		stores, database, err := openReadOnly(cfg, path)
+		return stores, database, nil, err
+	}
+
+	var l *lock.Lock
+	if exclusive {
+		if l, err = lockStorage(path); err != nil {
+			return models.Stores{}, nil, nil, err
+		}
+	}
+	stores, database, err := openBackend(cfg, path)
+	if err != nil {
+		l.Release()
+		return models.Stores{}, nil, nil, err
+	}
+	return stores, database, l, nil
+}
+
+// openReadOnly opens the existing storage file at path without write
+// access.
+func openReadOnly(cfg *config.Config, path string) (models.Stores, *db.TaskDB, error) {
+	var stores models.Stores
+	var database *db.TaskDB
+	if cfg.Backend == config.BackendJSON {
+		store, err := memstore.OpenFileReadOnly(path)
+		if err != nil {
+			return models.Stores{}, nil, err
+		}
+		stores = store.Stores()
+	} else {
+		var err error
+		if database, err = db.OpenReadOnly(path); err != nil {
+			return models.Stores{}, nil, err
+		}
+		stores = sqlStores(database)
+	}
+	stores.ReadOnly = true
+	return stores, database, nil
+}
+
+// openBackend opens (creating if needed) the storage file at path with the
+// configured backend.
+func openBackend(cfg *config.Config, path string) (models.Stores, *db.TaskDB, error) {
+	switch cfg.Backend {
+	case config.BackendMemory:
+		return memstore.New().Stores(), nil, nil
+	case config.BackendJSON:
+		store, err := memstore.OpenFile(path)
+		if err != nil {
+			return models.Stores{}, nil, err
+		}
+		return store.Stores(), nil, nil
+	default:
+		database, err := db.Open(path)
+		if err != nil {
+			return models.Stores{}, nil, err
+		}
+		return sqlStores(database), database, nil
+	}
+}
+
+// sqlStores returns the stores of an open database.
+func sqlStores(database *db.TaskDB) models.Stores {
+	stores := models.NewSQLStores(database)
+	indexed, err := database.SearchIndexAvailable()
+	stores.SubstringSearch = err != nil || !indexed
+	return stores
+}
+
+// ========= END STORAGE SECTION =========
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ========= TAGS SECTION =========
+
+// boardTags are the tags of the open board, sorted by name.
+type boardTags []models.Tag
+
+// find returns the tag called name, in any case.
+func (tags boardTags) find(name string) (models.Tag, bool) {
+	for _, tag := range tags {
+		if strings.EqualFold(tag.Name, strings.TrimSpace(name)) {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+func (tags boardTags) names() []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// color returns the color a tag is drawn in: its own, or one picked from
+// the column palette by its name so it stays the same between runs.
+func (tags boardTags) color(name string) string {
+	if tag, ok := tags.find(name); ok && tag.Color != "" {
+		return tag.Color
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	palette := columnPalette[1:]
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// badge renders a tag as it appears on cards.
+func (tags boardTags) badge(name string) string {
+	colors, _ := colorsOf(models.StatusColumn{Color: tags.color(name)})
+	return lipgloss.NewStyle().Background(colors.title).Foreground(colors.titleText).Padding(0, 1).Render(name)
+}
+
+// cardDelegate draws tasks with their priority marker, and their tags as
+// badges on a line under the description. Boards without tags keep
+// two-line cards.
+type cardDelegate struct {
+	list.DefaultDelegate
+	tags boardTags
+}
+
+func (d cardDelegate) Height() int {
+	if len(d.tags) == 0 {
+		return d.DefaultDelegate.Height()
+	}
+	return d.DefaultDelegate.Height() + 1
+}
+
+func (d cardDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	renderCard(d.DefaultDelegate, w, m, index, item)
+	if len(d.tags) == 0 {
+		return
+	}
+	task, ok := item.(models.Task)
+	if !ok {
+		return
+	}
+
+	style := d.Styles.NormalDesc
+	if index == m.Index() && m.FilterState() != list.Filtering {
+		style = d.Styles.SelectedDesc
+	}
+	width := m.Width() - style.GetHorizontalFrameSize()
+	var badges string
+	for _, name := range models.ParseTags(task.Tags) {
+		next := badges + d.tags.badge(name) + " "
+		if lipgloss.Width(next) > width {
+			badges += "…"
+			break
+		}
+		badges = next
+	}
+	fmt.Fprint(w, "\n"+style.Render(badges))
+}
+
+// addTags adds the tags in a task's Tags that the board does not have yet,
+// so they show and complete right away instead of once the task is saved.
+func (m *Model) addTags(tags string) {
+	added := false
+	for _, name := range models.ParseTags(tags) {
+		if _, ok := m.tags.find(name); !ok {
+			m.tags = append(m.tags, models.Tag{BoardId: m.board.Id, Name: name})
+			added = true
+		}
+	}
+	if added {
+		sort.Slice(m.tags, func(i, j int) bool { return strings.ToLower(m.tags[i].Name) < strings.ToLower(m.tags[j].Name) })
+		m.styleColumns()
+	}
+}
+
+// styleColumns redraws the columns after their colors or the board's tags
+// changed.
+func (m *Model) styleColumns() {
+	for i, column := range m.board.Columns {
+		m.columns[i] = styleColumnList(m.columns[i], column, m.tags)
+	}
+}
+
+// canonicalTags cleans up tags typed into the task form, spelling the ones
+// the board already has as the board does.
+func (m *Model) canonicalTags(tags string) string {
+	names := models.ParseTags(tags)
+	for i, name := range names {
+		if tag, ok := m.tags.find(name); ok {
+			names[i] = tag.Name
+		}
+	}
+	return models.FormatTags(names)
+}
+
+// tagSuggestions completes the last tag of value, a comma-separated list,
+// with the names not already in it.
+func tagSuggestions(value string, names []string) []string {
+	head := ""
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		rest := value[i+1:]
+		head = value[:i+1] + rest[:len(rest)-len(strings.TrimLeft(rest, " "))]
+	}
+	var suggestions []string
+	for _, name := range names {
+		if !models.HasTag(head, name) {
+			suggestions = append(suggestions, head+name)
+		}
+	}
+	return suggestions
+}
+
+// tagFilter narrows the board to the tasks with one tag.
+type tagFilter struct {
+	input textinput.Model
+	tag   string // Only tasks with this tag are shown, or every task if ""
+}
+
+// openTagFilter asks for the tag to filter by.
+func (m *Model) openTagFilter() tea.Cmd {
+	names := m.tags.names()
+	if len(names) == 0 && m.tagFilter.tag == "" {
+		return m.notify(severityWarning, "No task on this board has tags")
+	}
+	input := textinput.New()
+	input.Prompt = "Tag: "
+	input.Placeholder = "empty to show every task"
+	input.CharLimit = 50
+	input.Width = 30
+	input.ShowSuggestions = true
+	input.SetSuggestions(names)
+	input.SetValue(m.tagFilter.tag)
+	m.tagFilter.input = input
+	m.mode = TagFilter
+	return m.tagFilter.input.Focus()
+}
+
+// filterByTag shows only the tasks tagged name, or every task for "".
+func (m *Model) filterByTag(name string) tea.Cmd {
+	name = strings.TrimSpace(name)
+	if name != "" {
+		tag, ok := m.tags.find(name)
+		if !ok {
+			return m.notify(severityWarning, "No task on this board is tagged %q", name)
+		}
+		name = tag.Name
+	}
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	m.tagFilter.tag = name
+	if err := m.initColumnsFromDB(); err != nil {
+		return m.report(err)
+	}
+	m.handleWindowSize(m.width, m.height)
+	return nil
+}
+
+func handleTagFilter(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc":
+		m.mode = Normal
+		return m, nil
+	case "enter":
+		m.mode = Normal
+		return m, m.filterByTag(m.tagFilter.input.Value())
+	}
+	var cmd tea.Cmd
+	m.tagFilter.input, cmd = m.tagFilter.input.Update(msg)
+	return m, cmd
+}
+
+// colorTag handles :tag <name> [#color], which sets the color of a tag of
+// the open board, or goes back to its default color without one.
+func (m *Model) colorTag(args string) tea.Cmd {
+	if m.readonly {
+		return m.report(models.ErrReadOnly)
+	}
+	name, color := args, ""
+	if i := strings.LastIndex(args, " "); i >= 0 && strings.HasPrefix(args[i+1:], "#") {
+		name, color = strings.TrimSpace(args[:i]), args[i+1:]
+	}
+	if !colorPattern.MatchString(color) {
+		return m.notify(severityWarning, "Colors are written #rgb or #rrggbb")
+	}
+	if !m.idle() {
+		// Tags being saved may not exist yet
+		return m.report(errStillSaving)
+	}
+
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	tags, err := m.tagRepo.GetByBoardId(ctx, m.board.Id)
+	if err != nil {
+		return m.report(err)
+	}
+	tag, ok := boardTags(tags).find(name)
+	if !ok {
+		return m.notify(severityWarning, "No tag %q on this board", name)
+	}
+	store := m.tagRepo
+	return m.save(func(ctx context.Context) error {
+		return store.SetColor(ctx, tag.Id, color)
+	}, func(m *Model) tea.Cmd {
+		for i := range m.tags {
+			if m.tags[i].Id == tag.Id {
+				m.tags[i].Color = color
+			}
+		}
+		m.styleColumns()
+		return nil
+	})
+}
+
+// ========= END TAGS SECTION =========
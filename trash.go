@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= TRASH SECTION =========
+
+// trashItem shows a deleted task with how long ago it was deleted.
+type trashItem struct {
+	task models.Task
+}
+
+func (i trashItem) Title() string       { return i.task.Title() }
+func (i trashItem) FilterValue() string { return i.task.Title() }
+func (i trashItem) Description() string {
+	if i.task.DeletedAt == nil {
+		return ""
+	}
+	return "deleted " + formatAge(time.Since(*i.task.DeletedAt))
+}
+
+// formatAge renders a duration as a short, human-friendly age.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+type trashPane struct {
+	list         list.Model
+	confirmPurge bool
+}
+
+// openTrash loads the current board's deleted tasks and switches to the
+// trash view.
+func (m *Model) openTrash() error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	tasks, err := m.taskRepo.GetDeletedByBoardId(ctx, m.board.Id)
+	if err != nil {
+		return err
+	}
+
+	items := make([]list.Item, len(tasks))
+	for i := range tasks {
+		items[i] = trashItem{tasks[i]}
+	}
+	lm := list.New(items, createListDelegate(), 0, 0)
+	lm.Title = "Trash"
+	lm = styleListModel(lm)
+
+	m.trash = trashPane{list: lm}
+	m.resizeTrash()
+	m.mode = Trash
+	return nil
+}
+
+func (m *Model) resizeTrash() {
+	vertical, horizontal := columnStyle.GetFrameSize()
+	m.trash.list.SetSize(m.width-horizontal-2, m.height-17-vertical)
+}
+
+func (m *Model) selectedTrashItem() (trashItem, bool) {
+	item, ok := m.trash.list.SelectedItem().(trashItem)
+	return item, ok
+}
+
+// dropFromTrash removes a task from the trash list, wherever the list has
+// been scrolled or filtered to since the task was picked.
+func (m *Model) dropFromTrash(id int64) tea.Cmd {
+	for i, item := range m.trash.list.Items() {
+		if item.(trashItem).task.Id == id {
+			return removeItem(&m.trash.list, i)
+		}
+	}
+	return nil
+}
+
+// restoreFromTrash queues putting the selected task back in its column.
+func (m *Model) restoreFromTrash() tea.Cmd {
+	item, ok := m.selectedTrashItem()
+	if !ok {
+		return nil
+	}
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	tasks := m.taskRepo
+	return m.save(func(ctx context.Context) error {
+		return tasks.Restore(ctx, item.task.Id)
+	}, func(m *Model) tea.Cmd {
+		removed := m.dropFromTrash(item.task.Id)
+		m.undoStack.record(restoreOp(item.task.Id))
+		task := item.task
+		task.DeletedAt = nil
+		task.Version++
+		m.setStoredVersion(task.Id, task.Version)
+		if i := m.columnIndex(task.StatusColumnId); i >= 0 {
+			// Back at its rank, where the next load will show it too
+			inserted := m.columns[i].InsertItem(min(task.Position, len(m.columns[i].Items())), task)
+			return tea.Batch(removed, m.columnCmd(i, inserted))
+		}
+		return removed
+	})
+}
+
+// purgeFromTrash queues permanently deleting the selected task.
+func (m *Model) purgeFromTrash() tea.Cmd {
+	item, ok := m.selectedTrashItem()
+	if !ok {
+		return nil
+	}
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	tasks := m.taskRepo
+	return m.save(func(ctx context.Context) error {
+		return tasks.Purge(ctx, item.task.Id)
+	}, func(m *Model) tea.Cmd {
+		m.undoStack.forget(item.task.Id)
+		removed := m.dropFromTrash(item.task.Id)
+
+		// Tags only the purged task had are gone with it
+		ctx, cancel := m.dbContext()
+		defer cancel()
+		tags, err := m.tagRepo.GetByBoardId(ctx, m.board.Id)
+		if err != nil {
+			return tea.Batch(removed, m.report(err))
+		}
+		m.tags = tags
+		m.styleColumns()
+		return removed
+	})
+}
+
+func handleTrash(msg tea.KeyMsg, m *Model) (tea.Model, tea.Cmd) {
+	if m.trash.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.trash.list, cmd = m.trash.list.Update(msg)
+		return m, cmd
+	}
+
+	if m.trash.confirmPurge {
+		m.trash.confirmPurge = false
+		if msg.String() == "y" {
+			return m, m.purgeFromTrash()
+		}
+		return m, nil
+	}
+
+	key := msg.String()
+	if m.readonly && (key == "r" || key == "x") {
+		return m, nil
+	}
+	switch key {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc", "q", "t":
+		m.mode = Normal
+	case "r":
+		return m, m.restoreFromTrash()
+	case "x":
+		if _, ok := m.selectedTrashItem(); ok {
+			m.trash.confirmPurge = true
+		}
+	default:
+		var cmd tea.Cmd
+		m.trash.list, cmd = m.trash.list.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m Model) trashView() string {
+	help := "\nr to restore, x to delete forever, / to filter, esc to go back\n"
+	if m.readonly {
+		help = "\n/ to filter, esc to go back\n"
+	}
+	if m.trash.confirmPurge {
+		item, _ := m.selectedTrashItem()
+		help = fmt.Sprintf("\nDelete %q forever? y to confirm, any other key to cancel\n", item.Title())
+	}
+	return focusedColumnStyle.
+		Width(m.width-2).
+		Render(m.trash.list.View()) + help
+}
+
+// ========= END TRASH SECTION =========
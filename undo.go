@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+
+	"kanban/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= UNDO SECTION =========
+
+// undoLimit caps how many operations can be undone in one session.
+const undoLimit = 100
+
+// operation is a reversible change to a task or a column. Both directions
+// go through the stores on the write queue, and the board is reloaded
+// once they land, so the database and the columns on screen never
+// disagree.
+type operation struct {
+	taskId int64 // Zero for column operations
+
+	// Set by column operations, for showing the column afterwards
+	boardId  int64
+	columnId int64
+
+	undo step
+	redo step
+}
+
+type step func(ctx context.Context, s models.Stores) error
+
+// undoStack holds the operations of this session. Recording a new
+// operation forgets everything that was undone.
+type undoStack struct {
+	done   []operation
+	undone []operation
+}
+
+func (s *undoStack) record(op operation) {
+	s.done = append(s.done, op)
+	if len(s.done) > undoLimit {
+		s.done = s.done[len(s.done)-undoLimit:]
+	}
+	s.undone = nil
+}
+
+// forget drops every operation on a task that no longer exists.
+func (s *undoStack) forget(taskId int64) {
+	keep := func(ops []operation) []operation {
+		var kept []operation
+		for _, op := range ops {
+			if op.taskId != taskId {
+				kept = append(kept, op)
+			}
+		}
+		return kept
+	}
+	s.done = keep(s.done)
+	s.undone = keep(s.undone)
+}
+
+// createOp undoes a create by moving the task to the trash.
+func createOp(id int64) operation {
+	return operation{
+		taskId: id,
+		undo:   func(ctx context.Context, s models.Stores) error { return s.Tasks.Delete(ctx, id) },
+		redo:   func(ctx context.Context, s models.Stores) error { return s.Tasks.Restore(ctx, id) },
+	}
+}
+
+// deleteOp undoes a delete by restoring the task from the trash.
+func deleteOp(id int64) operation {
+	return operation{
+		taskId: id,
+		undo:   func(ctx context.Context, s models.Stores) error { return s.Tasks.Restore(ctx, id) },
+		redo:   func(ctx context.Context, s models.Stores) error { return s.Tasks.Delete(ctx, id) },
+	}
+}
+
+// restoreOp is the inverse of deleteOp, for restores made from the trash.
+func restoreOp(id int64) operation {
+	op := deleteOp(id)
+	op.undo, op.redo = op.redo, op.undo
+	return op
+}
+
+// editOp swaps the fields the task form edits, title, description, tags,
+// priority and due date, between before and after, leaving any other
+// fields as they are now.
+func editOp(before, after models.Task) operation {
+	apply := func(from models.Task) step {
+		return func(ctx context.Context, s models.Stores) error {
+			task, err := s.Tasks.GetById(ctx, from.Id)
+			if err != nil {
+				return err
+			}
+			task.SetTitle(from.Title())
+			task.SetDescription(from.Description())
+			task.Tags = from.Tags
+			task.Priority = from.Priority
+			task.DueDate = from.DueDate
+			return s.Tasks.Update(ctx, task)
+		}
+	}
+	return operation{taskId: after.Id, undo: apply(before), redo: apply(after)}
+}
+
+// moveOp puts a moved task back where it was, or moves it to position in
+// the target column again.
+func moveOp(task models.Task, columnId int64, position int) operation {
+	return operation{
+		taskId: task.Id,
+		undo: func(ctx context.Context, s models.Stores) error {
+			return s.Tasks.MoveToColumn(ctx, task.Id, task.StatusColumnId, task.Position)
+		},
+		redo: func(ctx context.Context, s models.Stores) error {
+			return s.Tasks.MoveToColumn(ctx, task.Id, columnId, position)
+		},
+	}
+}
+
+// sendOp sends a task that was sent to columnId, possibly on another
+// board, back to where it was.
+func sendOp(task models.Task, columnId int64) operation {
+	return operation{
+		taskId: task.Id,
+		undo: func(ctx context.Context, s models.Stores) error {
+			return s.Tasks.MoveToBoard(ctx, task.Id, task.StatusColumnId, task.Position)
+		},
+		redo: func(ctx context.Context, s models.Stores) error {
+			return s.Tasks.MoveToBoard(ctx, task.Id, columnId, 0)
+		},
+	}
+}
+
+// assignOp swaps the assignee of a task between before and after.
+func assignOp(id int64, before, after string) operation {
+	apply := func(assignee string) step {
+		return func(ctx context.Context, s models.Stores) error {
+			task, err := s.Tasks.GetById(ctx, id)
+			if err != nil {
+				return err
+			}
+			task.Assignee = assignee
+			return s.Tasks.Update(ctx, task)
+		}
+	}
+	return operation{taskId: id, undo: apply(before), redo: apply(after)}
+}
+
+// undo reverts the most recent operation once every write has landed.
+func (m *Model) undo() tea.Cmd {
+	s := &m.undoStack
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	if len(s.done) == 0 {
+		return m.notify(severityInfo, "Nothing to undo")
+	}
+	op := s.done[len(s.done)-1]
+	return m.applyOperation(op, op.undo, func(s *undoStack) {
+		s.done = s.done[:len(s.done)-1]
+		s.undone = append(s.undone, op)
+	})
+}
+
+// redo reapplies the most recently undone operation once every write has
+// landed.
+func (m *Model) redo() tea.Cmd {
+	s := &m.undoStack
+	if !m.idle() {
+		return m.report(errStillSaving)
+	}
+	if len(s.undone) == 0 {
+		return m.notify(severityInfo, "Nothing to redo")
+	}
+	op := s.undone[len(s.undone)-1]
+	return m.applyOperation(op, op.redo, func(s *undoStack) {
+		s.undone = s.undone[:len(s.undone)-1]
+		s.done = append(s.done, op)
+	})
+}
+
+// perform queues a new operation, recording it for undo and showing the
+// board as stored once it lands.
+func (m *Model) perform(op operation) tea.Cmd {
+	return m.applyOperation(op, op.redo, func(s *undoStack) { s.record(op) })
+}
+
+// applyOperation queues one direction of op. Once it lands, done files op
+// on the undo stack and the operation is shown, along with My Tasks when
+// it is open. A failed operation leaves the stack as it was.
+func (m *Model) applyOperation(op operation, apply step, done func(s *undoStack)) tea.Cmd {
+	stores := m.stores()
+	return m.enqueue(func(m *Model) (func(context.Context) error, func(*Model, error) tea.Cmd) {
+		run := func(ctx context.Context) error { return apply(ctx, stores) }
+		finish := func(m *Model, err error) tea.Cmd {
+			if err != nil {
+				return nil
+			}
+			done(&m.undoStack)
+			if err := m.showOperation(op); err != nil {
+				return m.report(err)
+			}
+			if m.mode == MyTasks {
+				return m.report(m.loadMyTasks())
+			}
+			return nil
+		}
+		return run, finish
+	})
+}
+
+// showOperation shows the board holding the task of op, selecting the task
+// if it is not in the trash. Column operations show their column instead.
+func (m *Model) showOperation(op operation) error {
+	ctx, cancel := m.dbContext()
+	defer cancel()
+	if op.taskId == 0 {
+		focused := m.focused
+		if err := m.openBoard(op.boardId); err != nil {
+			return err
+		}
+		if i := m.columnIndex(op.columnId); i >= 0 {
+			m.focused = i
+		} else {
+			// The column is gone; stay next to where it was
+			m.focused = max(0, min(focused, len(m.columns)-1))
+		}
+		return nil
+	}
+	task, err := m.taskRepo.GetById(ctx, op.taskId)
+	if err != nil {
+		return err
+	}
+
+	focused := m.focused
+	if task.BoardId != m.board.Id {
+		focused = 0
+	}
+	if err := m.openBoard(task.BoardId); err != nil {
+		return err
+	}
+	if !m.selectTask(task.Id) && focused < len(m.columns) {
+		m.focused = focused
+	}
+	return nil
+}
+
+// stores bundles the model's stores for operations spanning several.
+func (m *Model) stores() models.Stores {
+	return models.Stores{Boards: m.boardRepo, Columns: m.columnRepo, Tasks: m.taskRepo, Tags: m.tagRepo}
+}
+
+// ========= END UNDO SECTION =========
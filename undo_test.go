@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"kanban/internal/memstore"
+	"kanban/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// storedState describes every board in storage, with its columns and their
+// tasks in order, for comparing before and after. Trashed tasks are left
+// out, as undoing a create leaves the task in the trash.
+func storedState(t *testing.T, m *Model) string {
+	t.Helper()
+	ctx := context.Background()
+	s := m.stores()
+	boards, err := s.Boards.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("get boards: %v", err)
+	}
+	var b strings.Builder
+	for _, board := range boards {
+		fmt.Fprintf(&b, "board %d %q\n", board.Id, board.Title)
+		columns, err := s.Columns.GetByBoardId(ctx, board.Id)
+		if err != nil {
+			t.Fatalf("get columns: %v", err)
+		}
+		for _, column := range columns {
+			fmt.Fprintf(&b, "  column %d %q %s %q at %d\n", column.Id, column.Name, column.Color, column.Sort, column.Position)
+			tasks, err := s.Tasks.GetByColumnId(ctx, column.Id)
+			if err != nil {
+				t.Fatalf("get tasks: %v", err)
+			}
+			for _, task := range tasks {
+				fmt.Fprintf(&b, "    task %d %q %q %q p%d %q\n",
+					task.Id, task.Title(), task.Description(), task.Tags, task.Priority, task.Assignee)
+			}
+		}
+	}
+	return b.String()
+}
+
+func TestUndoRedoRoundTrips(t *testing.T) {
+	tests := []struct {
+		name string
+		do   func(m *Model) tea.Cmd
+	}{
+		{"create", func(m *Model) tea.Cmd {
+			return m.createTask("three", "", "", 0, nil)
+		}},
+		{"edit", func(m *Model) tea.Cmd {
+			task, _ := m.getSelectedTask()
+			edited := task
+			edited.SetTitle("one, edited")
+			edited.SetDescription("more")
+			edited.Tags = "bug"
+			edited.Priority = 3
+			return m.saveTask(task, edited, m.columns[m.focused].Index())
+		}},
+		{"delete", func(m *Model) tea.Cmd {
+			task, _ := m.getSelectedTask()
+			return m.deleteTask(task)
+		}},
+		{"move", func(m *Model) tea.Cmd {
+			task, _ := m.getSelectedTask()
+			return m.moveTask(task, 1, 0)
+		}},
+		{"restore", func(m *Model) tea.Cmd {
+			if err := m.openTrash(); err != nil {
+				t.Fatalf("open trash: %v", err)
+			}
+			return m.restoreFromTrash()
+		}},
+		{"send", func(m *Model) tea.Cmd {
+			ctx := context.Background()
+			boards, _ := m.boardRepo.GetAll(ctx)
+			for _, board := range boards {
+				if board.Id != m.board.Id {
+					columns, _ := m.columnRepo.GetByBoardId(ctx, board.Id)
+					task, _ := m.getSelectedTask()
+					return m.sendTask(task, board, columns[1])
+				}
+			}
+			t.Fatal("no other board")
+			return nil
+		}},
+		{"assign", func(m *Model) tea.Cmd {
+			task, _ := m.getSelectedTask()
+			return m.assignToMe(task)
+		}},
+		{"add column", func(m *Model) tea.Cmd {
+			return m.addColumn("Review")
+		}},
+		{"update column", func(m *Model) tea.Cmd {
+			return m.updateColumn(func(c *models.StatusColumn) {
+				c.Name = "Backlog"
+				c.Color = "#123456"
+				c.Sort = models.SortPriority
+			})
+		}},
+		{"move column", func(m *Model) tea.Cmd {
+			return m.moveColumn(1)
+		}},
+		{"delete empty column", func(m *Model) tea.Cmd {
+			m.focused = 2
+			return m.startDeleteColumn()
+		}},
+		{"delete column moving tasks", func(m *Model) tea.Cmd {
+			m.columnPane.target = 2
+			return m.deleteColumn()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, memstore.New().Stores())
+			m.user = "me"
+			other := models.Board{Title: "Other"}
+			if err := m.boardRepo.CreateWithColumns(context.Background(), &other, defaultColumns()); err != nil {
+				t.Fatalf("create board: %v", err)
+			}
+			for _, title := range []string{"gone", "two", "one"} {
+				settle(t, m, m.createTask(title, "", "", 0, nil))
+			}
+			m.columns[0].Select(2)
+			gone, _ := m.getSelectedTask()
+			settle(t, m, m.deleteTask(gone))
+			m.columns[0].Select(0)
+			m.undoStack = undoStack{}
+
+			before := storedState(t, m)
+			settle(t, m, tt.do(m))
+			after := storedState(t, m)
+			if after == before {
+				t.Fatalf("nothing changed in storage")
+			}
+			if len(m.undoStack.done) != 1 {
+				t.Fatalf("%d operations recorded, want 1", len(m.undoStack.done))
+			}
+
+			for i := 0; i < 2; i++ {
+				settle(t, m, m.undo())
+				if got := storedState(t, m); got != before {
+					t.Fatalf("undo %d left\n%s\nwant\n%s", i+1, got, before)
+				}
+				settle(t, m, m.redo())
+				if got := storedState(t, m); got != after {
+					t.Fatalf("redo %d left\n%s\nwant\n%s", i+1, got, after)
+				}
+			}
+			if len(m.undoStack.done) != 1 || len(m.undoStack.undone) != 0 {
+				t.Errorf("undo stack holds %d done and %d undone, want 1 and 0", len(m.undoStack.done), len(m.undoStack.undone))
+			}
+		})
+	}
+}
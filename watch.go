@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"kanban/internal/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ========= WATCH SECTION =========
+
+// watchInterval is how often storage is checked for changes made by other
+// processes, such as a second TUI or a script.
+const watchInterval = time.Second
+
+type watchTickMsg struct{}
+
+// watchResultMsg carries the result of a check for outside changes, with
+// the open board as stored when storage changed.
+type watchResultMsg struct {
+	changed  bool
+	snapshot boardSnapshot
+	err      error
+}
+
+// boardSnapshot is a board as stored, with its tags and the tasks of each
+// of its columns.
+type boardSnapshot struct {
+	board *models.Board
+	tags  []models.Tag
+	tasks [][]models.Task
+}
+
+func loadSnapshot(ctx context.Context, s models.Stores, boardId int64) (boardSnapshot, error) {
+	board, err := s.Boards.GetById(ctx, boardId)
+	if err != nil {
+		return boardSnapshot{}, err
+	}
+	tags, err := s.Tags.GetByBoardId(ctx, boardId)
+	if err != nil {
+		return boardSnapshot{}, err
+	}
+	snapshot := boardSnapshot{board: board, tags: tags}
+	for _, column := range board.Columns {
+		tasks, err := s.Tasks.GetByColumnId(ctx, column.Id)
+		if err != nil {
+			return boardSnapshot{}, err
+		}
+		snapshot.tasks = append(snapshot.tasks, tasks)
+	}
+	return snapshot, nil
+}
+
+func watchTick() tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+// handleWatchTick checks for changes to storage in the background, so a
+// busy database never holds up the keyboard, and reads the open board
+// again when there are any. The next tick is only scheduled once the check
+// is back, so checks never overlap.
+func (m *Model) handleWatchTick() tea.Cmd {
+	if m.fatal != nil {
+		return watchTick()
+	}
+	watcher, stores, boardId, ctx := m.watcher, m.stores(), m.board.Id, m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+		defer cancel()
+		changed, err := watcher.Changed(ctx)
+		if err != nil || !changed {
+			return watchResultMsg{err: err}
+		}
+		snapshot, err := loadSnapshot(ctx, stores, boardId)
+		return watchResultMsg{changed: true, snapshot: snapshot, err: err}
+	}
+}
+
+// handleWatchResult reloads the board after an outside change when it is
+// safe to do so. While the user is typing, filtering or in an overlay, or
+// while writes are still being saved, the reload waits, so it never pulls
+// a list out from under an edit.
+//
+// This process's own writes change storage too. They are told apart by
+// the board read back, which shows nothing the screen does not already
+// when they are all there is to it. A change that cannot be told apart,
+// as when the board could not be read back, is taken to be an outside one.
+func (m *Model) handleWatchResult(msg watchResultMsg) tea.Cmd {
+	if msg.err != nil && !msg.changed {
+		// Most likely the database is busy; look again on the next tick
+		return watchTick()
+	}
+	if msg.changed && (msg.err != nil || !m.shows(msg.snapshot)) {
+		m.stale = true
+	}
+
+	if m.stale && m.canReload() {
+		if err := m.reloadBoard(); err != nil {
+			// Keep the board on screen and try again on the next tick
+			return tea.Batch(watchTick(), m.notify(severityWarning, "Could not reload the board: %v", err))
+		}
+		m.stale = false
+	}
+	return watchTick()
+}
+
+// shows reports whether the board on screen is the one in snapshot, with the
+// same columns and tags, and the same tasks in each column at the versions
+// this process last stored.
+func (m *Model) shows(snapshot boardSnapshot) bool {
+	board := snapshot.board
+	if board.Id != m.board.Id || board.Title != m.board.Title || len(m.columns) != len(board.Columns) ||
+		!slices.Equal(board.Columns, m.board.Columns) || !slices.Equal(boardTags(snapshot.tags), m.tags) {
+		return false
+	}
+	for i, column := range board.Columns {
+		stored := m.columnItems(column, snapshot.tasks[i])
+		same := slices.EqualFunc(m.columns[i].Items(), stored, func(shown, stored list.Item) bool {
+			a, b := shown.(models.Task), stored.(models.Task)
+			return a.Id == b.Id && m.storedVersion(a.Id, a.Version) == b.Version
+		})
+		if !same {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Model) canReload() bool {
+	if m.mode != Normal || !m.idle() {
+		return false
+	}
+	for i := range m.columns {
+		if m.columns[i].FilterState() != list.Unfiltered {
+			return false
+		}
+	}
+	return true
+}
+
+// reloadBoard rebuilds the columns from storage, keeping the focused column
+// and the selection of each column where they still exist.
+func (m *Model) reloadBoard() error {
+	var focusedId int64
+	selected := make(map[int64]int64)
+	indexes := make(map[int64]int)
+	for i, column := range m.board.Columns {
+		if i >= len(m.columns) {
+			break
+		}
+		if i == m.focused {
+			focusedId = column.Id
+		}
+		if task, ok := m.columns[i].SelectedItem().(models.Task); ok {
+			selected[column.Id] = task.Id
+		}
+		indexes[column.Id] = m.columns[i].Index()
+	}
+
+	ctx, cancel := m.dbContext()
+	board, err := m.boardRepo.GetById(ctx, m.board.Id)
+	cancel()
+	if errors.Is(err, models.ErrNotFound) {
+		// The board was deleted elsewhere
+		if err := m.loadBoard(); err != nil {
+			return err
+		}
+		return m.openBoard(m.board.Id)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.board = *board
+	if err := m.initColumnsFromDB(); err != nil {
+		return err
+	}
+	m.handleWindowSize(m.width, m.height)
+
+	m.focused = 0
+	for i, column := range m.board.Columns {
+		if column.Id == focusedId {
+			m.focused = i
+		}
+		m.columns[i].Select(indexes[column.Id])
+		for j, item := range m.columns[i].Items() {
+			if task, ok := item.(models.Task); ok && task.Id == selected[column.Id] {
+				m.columns[i].Select(j)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// ========= END WATCH SECTION =========
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"kanban/internal/db"
+	"kanban/internal/models"
+)
+
+// checkWatch runs one watch tick to the end, without reloading the board,
+// and reports whether it was found stale.
+func checkWatch(t *testing.T, m *Model) bool {
+	t.Helper()
+	mode := m.mode
+	m.mode = Insert // Holds off the reload
+	defer func() { m.mode = mode }()
+
+	msg, ok := m.handleWatchTick()().(watchResultMsg)
+	if !ok {
+		t.Fatal("expected a watch result")
+	}
+	if msg.err != nil {
+		t.Fatalf("watch: %v", msg.err)
+	}
+	m.handleWatchResult(msg)
+	stale := m.stale
+	m.stale = false
+	return stale
+}
+
+func TestWatchTellsOwnWritesFromOutsideOnes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kanban.db")
+	open := func() models.Stores {
+		database, err := db.Open(path)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+		return sqlStores(database)
+	}
+	m := newTestModel(t, open())
+	outside := open()
+
+	if checkWatch(t, m) {
+		t.Error("stale with nothing written")
+	}
+
+	settle(t, m, m.createTask("one", "", "", 0, nil))
+	task, _ := m.getSelectedTask()
+	edited := task
+	edited.SetTitle("one, edited")
+	settle(t, m, m.saveTask(task, edited, 0))
+	if checkWatch(t, m) {
+		t.Error("stale after own writes only")
+	}
+
+	// An outside write landing alongside an own one still counts
+	theirs := models.Task{BoardId: m.board.Id, StatusColumnId: m.board.Columns[1].Id}
+	theirs.SetTitle("theirs")
+	if err := outside.Tasks.Create(context.Background(), &theirs); err != nil {
+		t.Fatalf("outside create: %v", err)
+	}
+	settle(t, m, m.createTask("two", "", "", 0, nil))
+	if !checkWatch(t, m) {
+		t.Error("not stale after an outside write")
+	}
+}